@@ -0,0 +1,65 @@
+package actions
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+type Output struct {
+	Name, Value string
+}
+
+func WriteOutputs(path string, outputs []Output) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600) // #nosec G304
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	for _, output := range outputs {
+		delimiter, err := delimiter(output.Value)
+		if err != nil {
+			return err
+		}
+
+		if _, err := fmt.Fprintf(file, "%s<<%s\n%s\n%s\n", output.Name, delimiter, output.Value, delimiter); err != nil {
+			return err
+		}
+	}
+
+	return file.Close()
+}
+
+func Error(title, message string) string {
+	return fmt.Sprintf("::error title=%s::%s", escapeProperty(title), escapeData(message))
+}
+
+// Mask hides the value from the rest of the workflow log.
+func Mask(value string) string {
+	return "::add-mask::" + escapeData(value)
+}
+
+func escapeData(in string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(in)
+}
+
+func escapeProperty(in string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(in)
+}
+
+func delimiter(value string) (string, error) {
+	for {
+		raw := make([]byte, 16)
+		if _, err := rand.Read(raw); err != nil {
+			return "", err
+		}
+
+		out := "ghadelimiter_" + hex.EncodeToString(raw)
+		if !strings.Contains(value, out) {
+			return out, nil
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package actions
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestWriteOutputs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "output")
+
+	if err := os.WriteFile(path, []byte("existing=1\n"), 0o600); err != nil {
+		t.Fatalf("os.WriteFile: %v", err)
+	}
+
+	outputs := []Output{
+		{"version", "v1.2"},
+		{"changelog", "#### New features:\n- [x](url)\n"},
+	}
+
+	if err := WriteOutputs(path, outputs); err != nil {
+		t.Fatalf("WriteOutputs() err = %v", err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("os.ReadFile: %v", err)
+	}
+
+	re := regexp.MustCompile(`^existing=1\nversion<<(ghadelimiter_\w+)\nv1.2\n(ghadelimiter_\w+)\nchangelog<<(ghadelimiter_\w+)\n#### New features:\n- \[x\]\(url\)\n\n(ghadelimiter_\w+)\n$`)
+
+	matches := re.FindStringSubmatch(string(raw))
+	if matches == nil {
+		t.Fatalf("WriteOutputs() got = %q", raw)
+	}
+
+	if matches[1] != matches[2] || matches[3] != matches[4] {
+		t.Errorf("WriteOutputs() delimiters mismatch: %q", raw)
+	}
+}
+
+func TestError(t *testing.T) {
+	got := Error("Invalid commit: abc, def", "100% wrong\nsecond line")
+	want := "::error title=Invalid commit%3A abc%2C def::100%25 wrong%0Asecond line"
+
+	if got != want {
+		t.Errorf("Error() got = %q, want %q", got, want)
+	}
+}
+
+func TestMask(t *testing.T) {
+	if got, want := Mask("ghs_abc\n"), "::add-mask::ghs_abc%0A"; got != want {
+		t.Errorf("Mask() got = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,104 @@
+package actions
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/agukrapo/tagger/versions"
+)
+
+func WriteSummary(path, markdown string) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600) // #nosec G304
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(markdown); err != nil {
+		return err
+	}
+
+	return file.Close()
+}
+
+func Summary(res versions.Result, dryRun bool) string {
+	var sb strings.Builder
+
+	if res.Bump == versions.NoBump && !res.Tagged {
+		sb.WriteString("### No release\n\n")
+
+		if len(res.Commits) == 0 {
+			fmt.Fprintf(&sb, "No commits since `%s`.\n", res.Previous)
+			return sb.String()
+		}
+
+		fmt.Fprintf(&sb, "No releasable commits since `%s`.\n", res.Previous)
+		if len(res.Ignored) != 0 {
+			fmt.Fprintf(&sb, "\nIgnored commit types: `%s`.\n", strings.Join(res.Ignored, "`, `"))
+		}
+
+		return sb.String()
+	}
+
+	title := "Released"
+	if dryRun {
+		title = "Dry run"
+	}
+
+	fmt.Fprintf(&sb, "### %s %s\n\n", title, res.Version)
+	fmt.Fprintf(&sb, "`%s` → `%s` (%s bump)\n", res.Previous, res.Version, res.Bump)
+
+	if res.Tagged {
+		fmt.Fprintf(&sb, "\n`%s` was already tagged, its release was reconciled.\n", res.Version)
+	}
+
+	if res.Release.URL != "" {
+		page := "Release page"
+		if res.Release.Draft {
+			page = "Draft release page, publish it after review"
+		}
+		fmt.Fprintf(&sb, "\n[%s](%s)\n", page, res.Release.URL)
+	}
+
+	sb.WriteString("\n#### Bump reason\n")
+	for _, commit := range res.Triggers {
+		fmt.Fprintf(&sb, "- `%s` %s\n", short(commit.SHA()), commit.Subject())
+	}
+
+	if res.Release.ChangeLog != "" {
+		fmt.Fprintf(&sb, "\n#### Changelog\n%s", res.Release.ChangeLog)
+	}
+
+	if len(res.Release.Assets) != 0 {
+		sb.WriteString("\n#### Assets\n| Name | Size |\n| --- | --- |\n")
+		for _, asset := range res.Release.Assets {
+			fmt.Fprintf(&sb, "| %s | %s |\n", asset.Name, size(asset.Size))
+		}
+	}
+
+	return sb.String()
+}
+
+func short(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+
+	return sha
+}
+
+func size(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
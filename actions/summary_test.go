@@ -0,0 +1,75 @@
+package actions
+
+import (
+	"testing"
+
+	"github.com/agukrapo/tagger/versions"
+)
+
+func TestSummary(t *testing.T) {
+	tests := []struct {
+		name   string
+		res    versions.Result
+		dryRun bool
+		want   string
+	}{
+		{
+			name: "no commits",
+			res:  versions.Result{},
+			want: "### No release\n\nNo commits since `v0`.\n",
+		},
+		{
+			name: "no releasable commits",
+			res: versions.Result{
+				Changes: versions.Changes{Commits: []*versions.Commit{versions.NewCommit("a", "docs: x")}},
+				Ignored: []string{"docs", "chore"},
+			},
+			want: "### No release\n\nNo releasable commits since `v0`.\n\nIgnored commit types: `docs`, `chore`.\n",
+		},
+		{
+			name: "release",
+			res: versions.Result{
+				Bump:     versions.MinorBump,
+				Triggers: []*versions.Commit{versions.NewCommit("0123456789", "feat: y")},
+				Release: versions.Release{
+					URL:       "https://example.com/release",
+					ChangeLog: "#### New features:\n- y\n",
+					Assets: []versions.ReleaseAsset{
+						{Name: "tagger", Size: 5 << 20},
+						{Name: "checksums.txt", Size: 180},
+					},
+				},
+			},
+			want: "### Released v0\n\n`v0` → `v0` (minor bump)\n\n[Release page](https://example.com/release)\n\n" +
+				"#### Bump reason\n- `0123456` feat: y\n\n" +
+				"#### Changelog\n#### New features:\n- y\n\n" +
+				"#### Assets\n| Name | Size |\n| --- | --- |\n| tagger | 5.0 MiB |\n| checksums.txt | 180 B |\n",
+		},
+		{
+			name: "draft",
+			res: versions.Result{
+				Bump:    versions.PatchBump,
+				Release: versions.Release{URL: "https://example.com/draft", Draft: true},
+			},
+			want: "### Released v0\n\n`v0` → `v0` (patch bump)\n\n[Draft release page, publish it after review](https://example.com/draft)\n\n#### Bump reason\n",
+		},
+		{
+			name: "already tagged",
+			res:  versions.Result{Changes: versions.Changes{Tagged: true}},
+			want: "### Released v0\n\n`v0` → `v0` (none bump)\n\n`v0` was already tagged, its release was reconciled.\n\n#### Bump reason\n",
+		},
+		{
+			name:   "dry run",
+			res:    versions.Result{Bump: versions.PatchBump},
+			dryRun: true,
+			want:   "### Dry run v0\n\n`v0` → `v0` (patch bump)\n\n#### Bump reason\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Summary(tt.res, tt.dryRun); got != tt.want {
+				t.Errorf("Summary() got = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
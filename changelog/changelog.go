@@ -0,0 +1,142 @@
+package changelog
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/agukrapo/tagger/versions"
+)
+
+type URLs interface {
+	Commit(sha string) string
+	Compare(base, head string) string
+	PullRequest(number int) string
+}
+
+type Renderer interface {
+	Render(Notes) (string, error)
+}
+
+type heading interface {
+	Heading(Notes) string
+}
+
+type Options struct {
+	Rules    versions.Rules
+	URLs     URLs
+	Renderer Renderer
+	Now      func() time.Time
+}
+
+type Generator struct {
+	opts Options
+}
+
+func New(opts Options) Generator {
+	return Generator{opts}
+}
+
+func (g Generator) Render(changes versions.Changes) (string, error) {
+	out, err := g.renderer().Render(g.Notes(changes))
+	if err != nil {
+		return "", fmt.Errorf("release notes: %w", err)
+	}
+
+	return out, nil
+}
+
+func (g Generator) Section(changes versions.Changes) (string, error) {
+	notes := g.Notes(changes)
+
+	body, err := g.renderer().Render(notes)
+	if err != nil {
+		return "", fmt.Errorf("release notes: %w", err)
+	}
+
+	if h, ok := g.renderer().(heading); ok {
+		return h.Heading(notes) + body, nil
+	}
+
+	return fmt.Sprintf("## %s (%s)\n\n%s", notes.Version, notes.Date.Format(time.DateOnly), body), nil
+}
+
+func (g Generator) renderer() Renderer {
+	if g.opts.Renderer == nil {
+		return Markdown
+	}
+
+	return g.opts.Renderer
+}
+
+func (g Generator) Notes(changes versions.Changes) Notes {
+	rules := g.opts.Rules
+	if rules == nil {
+		rules = versions.DefaultRules()
+	}
+
+	now := time.Now
+	if g.opts.Now != nil {
+		now = g.opts.Now
+	}
+
+	out := Notes{
+		Version: changes.Version.String(),
+		Date:    now().UTC(),
+	}
+
+	if changes.Tag != "" {
+		out.Previous = string(changes.Tag)
+		if g.opts.URLs != nil {
+			out.CompareURL = g.opts.URLs.Compare(string(changes.Tag), changes.Version.String())
+		}
+	}
+
+	sections := make(map[string][]Entry)
+	for _, commit := range changes.Commits {
+		entry := g.entry(commit)
+
+		title := rules.Section(commit)
+		sections[title] = append(sections[title], entry)
+
+		out.Types = group(out.Types, entry)
+		out.Contributors = contributors(out.Contributors, commit)
+	}
+
+	for _, title := range rules.Sections() {
+		if entries := sections[title]; len(entries) != 0 {
+			out.Sections = append(out.Sections, Section{title, entries})
+		}
+	}
+
+	return out
+}
+
+func (g Generator) entry(commit *versions.Commit) Entry {
+	change, msg := commit.Change()
+	header, _ := commit.Header()
+
+	out := Entry{
+		SHA:          commit.SHA(),
+		Type:         strings.ToLower(header.Type),
+		Scope:        header.Scope,
+		Description:  msg,
+		Subject:      commit.Subject(),
+		Body:         commit.Body(),
+		Breaking:     change == versions.Breaking,
+		BreakingNote: commit.BreakingNote(),
+
+		PullRequest:      commit.PullRequest().Number,
+		PullRequestTitle: commit.PullRequest().Title,
+		Author:           commit.Author(),
+	}
+
+	if g.opts.URLs != nil {
+		out.URL = g.opts.URLs.Commit(commit.SHA())
+		if out.PullRequest != 0 {
+			out.PullRequestURL = g.opts.URLs.PullRequest(out.PullRequest)
+		}
+	}
+
+	return out
+}
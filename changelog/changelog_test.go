@@ -0,0 +1,197 @@
+package changelog
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/agukrapo/tagger/versions"
+)
+
+type urls struct{}
+
+func (urls) Commit(sha string) string {
+	return "https://example.com/o/r/commit/" + sha
+}
+
+func (urls) Compare(base, head string) string {
+	return "https://example.com/o/r/compare/" + base + "..." + head
+}
+
+func (urls) PullRequest(number int) string {
+	return fmt.Sprintf("https://example.com/o/r/pull/%d", number)
+}
+
+func now() time.Time {
+	return time.Date(2025, 1, 2, 23, 0, 0, 0, time.UTC)
+}
+
+func TestGenerator_Render(t *testing.T) {
+	g := New(Options{URLs: urls{}})
+
+	commits := []*versions.Commit{
+		versions.NewCommit("a1", "feat: drop Node 6\n\nBREAKING CHANGE: Node 6 is EOL,\nupgrade to Node 8."),
+		versions.NewCommit("b2", "fix: prevent racing of requests"),
+		versions.NewCommit("c3", "docs: correct spelling"),
+	}
+
+	want := "#### Breaking changes:\n" +
+		"- [drop Node 6](https://example.com/o/r/commit/a1)\n" +
+		"  Node 6 is EOL,\n  upgrade to Node 8.\n" +
+		"#### Bug fixes:\n" +
+		"- [prevent racing of requests](https://example.com/o/r/commit/b2)\n" +
+		"#### Other:\n" +
+		"- [correct spelling](https://example.com/o/r/commit/c3)\n"
+
+	got, err := g.Render(versions.Changes{Commits: commits})
+	if err != nil {
+		t.Fatalf("Render() err = %v", err)
+	}
+
+	if got != want {
+		t.Errorf("Render() got = %q, want %q", got, want)
+	}
+}
+
+func TestGenerator_Render_rules(t *testing.T) {
+	rules := versions.DefaultRules().Set(versions.Rule{Type: "perf", Bump: versions.PatchBump, Section: "Performance"})
+	g := New(Options{Rules: rules, URLs: urls{}})
+
+	commits := []*versions.Commit{
+		versions.NewCommit("a1", "perf: faster"),
+		versions.NewCommit("b2", "feat: new"),
+	}
+
+	want := "#### New features:\n" +
+		"- [new](https://example.com/o/r/commit/b2)\n" +
+		"#### Performance:\n" +
+		"- [faster](https://example.com/o/r/commit/a1)\n"
+
+	got, err := g.Render(versions.Changes{Commits: commits})
+	if err != nil {
+		t.Fatalf("Render() err = %v", err)
+	}
+
+	if got != want {
+		t.Errorf("Render() got = %q, want %q", got, want)
+	}
+}
+
+func TestGenerator_Render_template(t *testing.T) {
+	tmpl, err := ParseTemplate("custom", `## {{.Version}} ({{date "2006-01-02" .Date}}) since {{.Previous}}
+{{.CompareURL}}
+{{range .Types}}{{.Name | upper}}
+{{range .Scopes}}{{with .Name}}  {{.}}:
+{{end}}{{range .Commits}}  - {{.Description}} {{short .SHA}}{{if .Breaking}} BREAKING{{end}}
+{{end}}{{end}}{{end}}`)
+	if err != nil {
+		t.Fatalf("ParseTemplate() err = %v", err)
+	}
+
+	g := New(Options{URLs: urls{}, Renderer: NewTemplate(tmpl), Now: now})
+
+	changes := versions.Changes{
+		Tag: "v1.2.0",
+		Commits: []*versions.Commit{
+			versions.NewCommit("0123456789", "feat(api): a"),
+			versions.NewCommit("1123456789", "fix: b"),
+			versions.NewCommit("2123456789", "feat(api)!: c"),
+			versions.NewCommit("3123456789", "feat(ui): d"),
+		},
+	}
+
+	got, err := g.Render(changes)
+	if err != nil {
+		t.Fatalf("Render() err = %v", err)
+	}
+
+	want := "## v0 (2025-01-02) since v1.2.0\n" +
+		"https://example.com/o/r/compare/v1.2.0...v0\n" +
+		"FEAT\n" +
+		"  api:\n" +
+		"  - a 0123456\n" +
+		"  - c 2123456 BREAKING\n" +
+		"  ui:\n" +
+		"  - d 3123456\n" +
+		"FIX\n" +
+		"  - b 1123456\n"
+
+	if got != want {
+		t.Errorf("Render() got = %q, want %q", got, want)
+	}
+}
+
+func TestGenerator_Render_templateError(t *testing.T) {
+	tmpl, err := ParseTemplate("custom", `{{.Unknown}}`)
+	if err != nil {
+		t.Fatalf("ParseTemplate() err = %v", err)
+	}
+
+	if _, err := New(Options{Renderer: NewTemplate(tmpl)}).Render(versions.Changes{}); err == nil {
+		t.Error("Render() err = nil")
+	}
+}
+
+func TestGenerator_Section(t *testing.T) {
+	changes := versions.Changes{Commits: []*versions.Commit{versions.NewCommit("a1", "feat: new")}}
+
+	tests := []struct {
+		name     string
+		renderer Renderer
+		want     string
+	}{
+		{
+			name: "markdown",
+			want: "## v0 (2025-01-02)\n\n#### New features:\n- new\n",
+		},
+		{
+			name:     "keep a changelog",
+			renderer: KeepAChangelog{},
+			want:     "## [v0] - 2025-01-02\n\n### Added\n\n- new\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(Options{Renderer: tt.renderer, Now: now}).Section(changes)
+			if err != nil {
+				t.Fatalf("Section() err = %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("Section() got = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerator_Render_pullRequests(t *testing.T) {
+	a := versions.NewCommit("a1", "feat: add Polish language")
+	a.SetAuthor("alice")
+	a.SetPullRequest(versions.PullRequest{Number: 123, Title: "Polish"})
+	a.SetFirstContribution(true)
+
+	b := versions.NewCommit("b2", "feat: add Czech language")
+	b.SetAuthor("alice")
+	b.SetPullRequest(versions.PullRequest{Number: 124})
+	b.SetFirstContribution(true)
+
+	c := versions.NewCommit("c3", "fix: typo")
+	c.SetAuthor("bob")
+
+	want := "#### New features:\n" +
+		"- [add Polish language](https://example.com/o/r/commit/a1) (#123) @alice\n" +
+		"- [add Czech language](https://example.com/o/r/commit/b2) (#124) @alice\n" +
+		"#### Bug fixes:\n" +
+		"- [typo](https://example.com/o/r/commit/c3) @bob\n" +
+		"#### New contributors:\n" +
+		"- @alice made their first contribution in #123\n"
+
+	got, err := New(Options{URLs: urls{}}).Render(versions.Changes{Commits: []*versions.Commit{a, b, c}})
+	if err != nil {
+		t.Fatalf("Render() err = %v", err)
+	}
+
+	if got != want {
+		t.Errorf("Render() got = %q, want %q", got, want)
+	}
+}
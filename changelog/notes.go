@@ -0,0 +1,99 @@
+package changelog
+
+import (
+	"time"
+
+	"github.com/agukrapo/tagger/versions"
+)
+
+type Entry struct {
+	SHA          string `json:"sha"`
+	URL          string `json:"url,omitempty"`
+	Type         string `json:"type,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+	Description  string `json:"description"`
+	Subject      string `json:"subject"`
+	Body         string `json:"body,omitempty"`
+	Breaking     bool   `json:"breaking"`
+	BreakingNote string `json:"breaking_note,omitempty"`
+
+	PullRequest      int    `json:"pull_request,omitempty"`
+	PullRequestTitle string `json:"pull_request_title,omitempty"`
+	PullRequestURL   string `json:"pull_request_url,omitempty"`
+	Author           string `json:"author,omitempty"`
+}
+
+type Contributor struct {
+	Login       string `json:"login"`
+	PullRequest int    `json:"pull_request,omitempty"`
+}
+
+type Section struct {
+	Title   string  `json:"title"`
+	Commits []Entry `json:"commits"`
+}
+
+type Scope struct {
+	Name    string  `json:"name"`
+	Commits []Entry `json:"commits"`
+}
+
+type Type struct {
+	Name   string  `json:"name"`
+	Scopes []Scope `json:"scopes"`
+}
+
+type Notes struct {
+	Version    string    `json:"version"`
+	Previous   string    `json:"previous,omitempty"`
+	Date       time.Time `json:"date"`
+	CompareURL string    `json:"compare_url,omitempty"`
+	Sections   []Section `json:"sections"`
+	Types      []Type    `json:"types"`
+
+	Contributors []Contributor `json:"contributors,omitempty"`
+}
+
+func contributors(in []Contributor, commit *versions.Commit) []Contributor {
+	if !commit.FirstContribution() || commit.Author() == "" {
+		return in
+	}
+
+	for _, c := range in {
+		if c.Login == commit.Author() {
+			return in
+		}
+	}
+
+	return append(in, Contributor{Login: commit.Author(), PullRequest: commit.PullRequest().Number})
+}
+
+func group(types []Type, entry Entry) []Type {
+	t := len(types)
+	for i := range types {
+		if types[i].Name == entry.Type {
+			t = i
+			break
+		}
+	}
+	if t == len(types) {
+		types = append(types, Type{Name: entry.Type})
+	}
+
+	scopes := types[t].Scopes
+	s := len(scopes)
+	for i := range scopes {
+		if scopes[i].Name == entry.Scope {
+			s = i
+			break
+		}
+	}
+	if s == len(scopes) {
+		scopes = append(scopes, Scope{Name: entry.Scope})
+	}
+
+	scopes[s].Commits = append(scopes[s].Commits, entry)
+	types[t].Scopes = scopes
+
+	return types
+}
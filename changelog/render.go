@@ -0,0 +1,167 @@
+package changelog
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	markdownTemplate = `{{range .Sections}}#### {{.Title}}:
+{{range .Commits}}- {{if .URL}}[{{.Description}}]({{.URL}}){{else}}{{.Description}}{{end}}{{with .PullRequest}} (#{{.}}){{end}}{{with .Author}} @{{.}}{{end}}
+{{with .BreakingNote}}  {{indent 2 .}}
+{{end}}{{end}}{{end}}{{with .Contributors}}#### New contributors:
+{{range .}}- @{{.Login}} made their first contribution{{with .PullRequest}} in #{{.}}{{end}}
+{{end}}{{end}}`
+
+	textTemplate = `{{range .Sections}}{{.Title}}:
+{{range .Commits}}  * {{.Description}} ({{short .SHA}}){{with .PullRequest}} #{{.}}{{end}}{{with .Author}} @{{.}}{{end}}
+{{with .BreakingNote}}    {{indent 4 .}}
+{{end}}{{end}}{{end}}{{with .Contributors}}New contributors:
+{{range .}}  * @{{.Login}}{{with .PullRequest}} #{{.}}{{end}}
+{{end}}{{end}}`
+)
+
+var funcs = template.FuncMap{
+	"indent": func(n int, in string) string {
+		return strings.ReplaceAll(in, "\n", "\n"+strings.Repeat(" ", n))
+	},
+	"short": shortSHA,
+	"date": func(layout string, t time.Time) string {
+		return t.Format(layout)
+	},
+	"lower":     strings.ToLower,
+	"upper":     strings.ToUpper,
+	"trim":      strings.TrimSpace,
+	"join":      strings.Join,
+	"replace":   strings.ReplaceAll,
+	"hasPrefix": strings.HasPrefix,
+}
+
+func ParseTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(funcs).Parse(text)
+}
+
+type Template struct {
+	tmpl *template.Template
+}
+
+func NewTemplate(tmpl *template.Template) Template {
+	return Template{tmpl}
+}
+
+func (t Template) Render(notes Notes) (string, error) {
+	var sb strings.Builder
+	if err := t.tmpl.Execute(&sb, notes); err != nil {
+		return "", err
+	}
+
+	return sb.String(), nil
+}
+
+var (
+	Markdown = NewTemplate(template.Must(ParseTemplate("markdown", markdownTemplate)))
+	Text     = NewTemplate(template.Must(ParseTemplate("text", textTemplate)))
+)
+
+type JSON struct{}
+
+func (JSON) Render(notes Notes) (string, error) {
+	raw, err := json.MarshalIndent(notes, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(raw) + "\n", nil
+}
+
+type KeepAChangelog struct{}
+
+var categories = []string{"Added", "Changed", "Removed", "Fixed"}
+
+func category(entry Entry) string {
+	switch entry.Type {
+	case "feat":
+		return "Added"
+	case "fix":
+		return "Fixed"
+	case "revert":
+		return "Removed"
+	}
+
+	return "Changed"
+}
+
+func (KeepAChangelog) Heading(notes Notes) string {
+	return fmt.Sprintf("## [%s] - %s\n\n", notes.Version, notes.Date.Format(time.DateOnly))
+}
+
+func (KeepAChangelog) Render(notes Notes) (string, error) {
+	grouped := make(map[string][]Entry)
+	for _, section := range notes.Sections {
+		for _, entry := range section.Commits {
+			c := category(entry)
+			grouped[c] = append(grouped[c], entry)
+		}
+	}
+
+	var sb strings.Builder
+	for _, c := range categories {
+		entries := grouped[c]
+		if len(entries) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&sb, "### %s\n\n", c)
+		for _, entry := range entries {
+			sb.WriteString("- ")
+			if entry.Breaking {
+				sb.WriteString("**BREAKING** ")
+			}
+			if entry.Scope != "" {
+				fmt.Fprintf(&sb, "**%s:** ", entry.Scope)
+			}
+			sb.WriteString(entry.Description)
+			switch {
+			case entry.PullRequestURL != "":
+				fmt.Fprintf(&sb, " ([#%d](%s))", entry.PullRequest, entry.PullRequestURL)
+			case entry.URL != "":
+				fmt.Fprintf(&sb, " ([%s](%s))", shortSHA(entry.SHA), entry.URL)
+			}
+			if entry.Author != "" {
+				fmt.Fprintf(&sb, " @%s", entry.Author)
+			}
+			sb.WriteString("\n")
+			if entry.BreakingNote != "" {
+				fmt.Fprintf(&sb, "  %s\n", strings.ReplaceAll(entry.BreakingNote, "\n", "\n  "))
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n"), nil
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+func Format(name string) (Renderer, error) {
+	switch strings.ToLower(name) {
+	case "", "markdown":
+		return Markdown, nil
+	case "text":
+		return Text, nil
+	case "json":
+		return JSON{}, nil
+	case "keep-a-changelog":
+		return KeepAChangelog{}, nil
+	}
+
+	return nil, fmt.Errorf("invalid format %q, want markdown, text, json or keep-a-changelog", name)
+}
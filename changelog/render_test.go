@@ -0,0 +1,80 @@
+package changelog
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/agukrapo/tagger/versions"
+)
+
+func notes() Notes {
+	changes := versions.Changes{
+		Tag: "v1",
+		Commits: []*versions.Commit{
+			versions.NewCommit("0123456789", "feat(api)!: drop v1\n\nBREAKING CHANGE: use v2,\nv1 is gone."),
+			versions.NewCommit("1123456789", "fix: b"),
+			versions.NewCommit("2123456789", "perf: c"),
+		},
+	}
+
+	return New(Options{URLs: urls{}, Now: now}).Notes(changes)
+}
+
+func TestText(t *testing.T) {
+	want := "Breaking changes:\n" +
+		"  * drop v1 (0123456)\n" +
+		"    use v2,\n    v1 is gone.\n" +
+		"Bug fixes:\n" +
+		"  * b (1123456)\n" +
+		"Other:\n" +
+		"  * c (2123456)\n"
+
+	got, err := Text.Render(notes())
+	if err != nil || got != want {
+		t.Errorf("Render() got = %q %v, want %q", got, err, want)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	got, err := JSON{}.Render(notes())
+	if err != nil {
+		t.Fatalf("Render() err = %v", err)
+	}
+
+	var decoded Notes
+	if err := json.Unmarshal([]byte(got), &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() err = %v", err)
+	}
+
+	if decoded.Version != "v0" || decoded.CompareURL != "https://example.com/o/r/compare/v1...v0" ||
+		len(decoded.Sections) != 3 || decoded.Sections[0].Commits[0].BreakingNote != "use v2,\nv1 is gone." {
+		t.Errorf("Render() got = %s", got)
+	}
+}
+
+func TestKeepAChangelog(t *testing.T) {
+	want := "### Added\n\n" +
+		"- **BREAKING** **api:** drop v1 ([0123456](https://example.com/o/r/commit/0123456789))\n" +
+		"  use v2,\n  v1 is gone.\n\n" +
+		"### Changed\n\n" +
+		"- c ([2123456](https://example.com/o/r/commit/2123456789))\n\n" +
+		"### Fixed\n\n" +
+		"- b ([1123456](https://example.com/o/r/commit/1123456789))\n"
+
+	got, err := KeepAChangelog{}.Render(notes())
+	if err != nil || got != want {
+		t.Errorf("Render() got = %q %v, want %q", got, err, want)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	for _, name := range []string{"", "markdown", "Text", "json", "keep-a-changelog"} {
+		if _, err := Format(name); err != nil {
+			t.Errorf("Format(%q) err = %v", name, err)
+		}
+	}
+
+	if _, err := Format("html"); err == nil {
+		t.Error("Format(html) err = nil")
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+var envFlags = []struct {
+	name, env, usage string
+	boolean          bool
+}{
+	{name: "api-url", env: "GITHUB_API_URL", usage: "GitHub API URL (default https://api.github.com)"},
+	{name: "repository", env: "GITHUB_REPOSITORY", usage: "owner/repository"},
+	{name: "token", env: "GITHUB_TOKEN", usage: "GitHub token, only needed by release"},
+	{name: "app-id", env: "GITHUB_APP_ID", usage: "GitHub App id, authenticates as the app installation instead of the token"},
+	{name: "app-private-key", env: "GITHUB_APP_PRIVATE_KEY", usage: "GitHub App PEM private key or its path"},
+	{name: "config", env: "TAGGER_CONFIG", usage: "configuration file path"},
+	{name: "pre-release", env: "PRE_RELEASE", usage: "pre-release channel, e.g. rc"},
+	{name: "assets", env: "RELEASE_ASSETS", usage: "newline separated release assets glob patterns"},
+	{name: "rules", env: "RELEASE_RULES", usage: "newline separated type=bump[:section] rules"},
+	{name: "dry-run", env: "DRY_RUN", usage: "compute everything without tagging nor releasing", boolean: true},
+	{name: "changelog-file", env: "CHANGELOG_FILE", usage: "changelog file to prepend the release notes to and commit before tagging"},
+	{name: "changelog-message", env: "CHANGELOG_MESSAGE", usage: "changelog commit message, {version} is replaced (default \"chore(release): {version}\")"},
+	{name: "changelog-author", env: "CHANGELOG_AUTHOR", usage: "changelog commit author, \"Name <email>\""},
+	{name: "template", env: "RELEASE_TEMPLATE", usage: "text/template file rendering the release notes and changelog file"},
+	{name: "format", env: "RELEASE_FORMAT", usage: "release notes format: markdown, text, json or keep-a-changelog"},
+	{name: "changelog-format", env: "CHANGELOG_FORMAT", usage: "changelog file format: markdown, text, json or keep-a-changelog"},
+	{name: "release-notes", env: "RELEASE_NOTES", usage: "release body source: conventional, github (generate-notes API) or merge"},
+	{name: "draft", env: "RELEASE_DRAFT", usage: "leave the release as a draft for manual review", boolean: true},
+	{name: "retries", env: "API_RETRIES", usage: "GitHub API retries on network errors, 5xx responses and rate limits (default 3)"},
+	{name: "api-timeout", env: "API_TIMEOUT", usage: "GitHub API request timeout, e.g. 30s (default 5m)"},
+	{name: "timeout", env: "TIMEOUT", usage: "overall run timeout, e.g. 10m (default none)"},
+	{name: "rollback", env: "RELEASE_ROLLBACK", usage: "delete the pushed tag and draft release when the release fails", boolean: true},
+	{name: "pull-requests", env: "RELEASE_PULL_REQUESTS", usage: "link pull requests and authors in the release notes", boolean: true},
+	{name: "contributors", env: "RELEASE_CONTRIBUTORS", usage: "list first-time contributors in the release notes", boolean: true},
+	{name: "tag-push", env: "TAG_PUSH", usage: "how release pushes the tag: git (default) or api"},
+	{name: "tag-annotated", env: "TAG_ANNOTATED", usage: "create an annotated tag", boolean: true},
+	{name: "tag-message", env: "TAG_MESSAGE", usage: "annotated tag message, {version} is replaced (default the release notes)"},
+	{name: "tag-tagger", env: "TAG_TAGGER", usage: "annotated tag tagger, \"Name <email>\""},
+	{name: "tag-sign", env: "TAG_SIGN", usage: "sign the git tag with gpg or ssh"},
+	{name: "tag-signing-key", env: "TAG_SIGNING_KEY", usage: "signing key: GPG key id or armored private key, SSH key path or private key"},
+	{name: "tag-allowed-signers", env: "TAG_ALLOWED_SIGNERS", usage: "SSH allowed signers file verifying the pushed tag (default derived from the signing key)"},
+	{name: "range", env: "LINT_RANGE", usage: "git revision range to lint, e.g. origin/main..HEAD"},
+	{name: "pull-request", env: "LINT_PULL_REQUEST", usage: "pull request number whose commits are linted through the GitHub API"},
+}
+
+type envFlag struct {
+	value   string
+	set     bool
+	boolean bool
+}
+
+func (f *envFlag) String() string {
+	return f.value
+}
+
+func (f *envFlag) Set(value string) error {
+	f.value, f.set = value, true
+	return nil
+}
+
+func (f *envFlag) IsBoolFlag() bool {
+	return f.boolean
+}
+
+type environment map[string]*envFlag
+
+func parseFlags(cmd command, args []string) (environment, error) {
+	fs := flag.NewFlagSet("tagger "+cmd.name, flag.ContinueOnError)
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(fs.Output(), "Usage: tagger %s [flags]\n\n%s\n\nFlags:\n", cmd.name, cmd.usage)
+		fs.PrintDefaults()
+	}
+
+	out := make(environment, len(envFlags))
+	for _, f := range envFlags {
+		out[f.env] = &envFlag{boolean: f.boolean}
+		fs.Var(out[f.env], f.name, fmt.Sprintf("%s, overrides %s", f.usage, f.env))
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if fs.NArg() != 0 {
+		return nil, fmt.Errorf("unexpected arguments %v", fs.Args())
+	}
+
+	return out, nil
+}
+
+func (l environment) lookup(name string) (string, bool) {
+	if f, ok := l[name]; ok && f.set {
+		return f.value, true
+	}
+
+	return os.LookupEnv(name)
+}
+
+func (l environment) require(name string) (string, error) {
+	if out, ok := l.lookup(name); ok && out != "" {
+		return out, nil
+	}
+
+	return "", fmt.Errorf("environment variable %s not set", name)
+}
@@ -1,83 +1,520 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
+	"github.com/agukrapo/tagger/actions"
+	"github.com/agukrapo/tagger/changelog"
+	"github.com/agukrapo/tagger/config"
 	"github.com/agukrapo/tagger/git"
 	"github.com/agukrapo/tagger/github"
 	"github.com/agukrapo/tagger/versions"
 )
 
 func main() {
-	if err := run(); err != nil {
-		_, _ = fmt.Fprintf(os.Stderr, "%v\n", err)
+	if err := run(os.Args[1:]); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			_, _ = fmt.Fprintf(os.Stderr, "%v\n", err)
+		}
 		os.Exit(1)
 	}
 }
 
-func run() error {
-	host, err := env("GITHUB_API_URL")
+type command struct {
+	name, usage string
+	run         func(ctx context.Context, env environment) error
+}
+
+var commands = []command{
+	{"next-version", "print the next version computed from the local git history", nextVersion},
+	{"changelog", "print the changelog of the commits since the latest tag", changeLog},
+	{"tag", "create and push the next version tag with git", tag},
+	{"release", "tag and create the GitHub release, the default command", release},
+	{"lint", "check commit messages follow Conventional Commits", lint},
+}
+
+func run(args []string) error {
+	name := "release"
+	if len(args) != 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+
+		env, err := parseFlags(cmd, args)
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		if raw, _ := env.lookup("TIMEOUT"); raw != "" {
+			timeout, err := time.ParseDuration(raw)
+			if err != nil {
+				return fmt.Errorf("TIMEOUT: %w", err)
+			}
+
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		return cmd.run(ctx, env)
+	}
+
+	usage()
+	return fmt.Errorf("unknown command %q", name)
+}
+
+func usage() {
+	_, _ = fmt.Fprintf(os.Stderr, "Usage: tagger [command] [flags]\n\nCommands:\n")
+	for _, cmd := range commands {
+		_, _ = fmt.Fprintf(os.Stderr, "  %-14s %s\n", cmd.name, cmd.usage)
+	}
+}
+
+func setup(ctx context.Context, env environment) (config.Config, git.Client, *github.Installation, error) {
+	cfg, err := config.Load(env.lookup)
+	if err != nil {
+		return config.Config{}, git.Client{}, nil, err
+	}
+
+	if cfg.Path != "" {
+		_, _ = fmt.Fprintln(os.Stderr, "Config file: ", cfg.Path)
+	}
+
+	opts := git.Options{
+		Workspace: cfg.Workspace,
+	}
+
+	if cfg.ChangeLog.File != "" {
+		gen, err := generator(env, cfg, cfg.ChangeLog.Format)
+		if err != nil {
+			return config.Config{}, git.Client{}, nil, fmt.Errorf("changelog file: %w", err)
+		}
+
+		opts.ChangeLog = git.ChangeLogOptions{
+			File:    cfg.ChangeLog.File,
+			Message: cfg.ChangeLog.Message,
+			Author:  cfg.ChangeLog.Author,
+			Render:  gen.Section,
+		}
+	}
+
+	opts.Tag = git.TagOptions{
+		Annotated:      cfg.Tag.Annotated,
+		Message:        cfg.Tag.Message,
+		Tagger:         cfg.Tag.Tagger,
+		Sign:           cfg.Tag.Sign,
+		SigningKey:     cfg.Tag.SigningKey,
+		AllowedSigners: cfg.Tag.AllowedSigners,
+	}
+
+	if (cfg.Tag.Annotated || cfg.Tag.Sign != "") && cfg.Tag.Message == "" {
+		gen, err := generator(env, cfg, cfg.Format)
+		if err != nil {
+			return config.Config{}, git.Client{}, nil, fmt.Errorf("tag message: %w", err)
+		}
+		opts.Tag.Render = gen.Render
+	}
+
+	app, err := installation(env, cfg)
+	if err != nil {
+		return config.Config{}, git.Client{}, nil, err
+	}
+
+	if app != nil {
+		server, _ := env.lookup("GITHUB_SERVER_URL")
+		opts.Credentials = git.Credentials{Server: server, Token: app.Token}
+	}
+
+	if v, _ := env.lookup("GITHUB_ACTIONS"); v != "true" {
+		return cfg, git.New(opts), app, nil
+	}
+
+	local, err := git.SetupClient(ctx, opts)
+
+	return cfg, local, app, err
+}
+
+// installation authenticates as the GitHub App when its id is set, so the pushed tags trigger workflows unlike GITHUB_TOKEN.
+func installation(env environment, cfg config.Config) (*github.Installation, error) {
+	id, _ := env.lookup("GITHUB_APP_ID")
+	if id == "" {
+		return nil, nil
+	}
+
+	key, err := env.require("GITHUB_APP_PRIVATE_KEY")
+	if err != nil {
+		return nil, err
+	}
+
+	raw := []byte(key)
+	if !strings.Contains(key, "PRIVATE KEY") {
+		if raw, err = os.ReadFile(filepath.Clean(key)); err != nil {
+			return nil, fmt.Errorf("app private key: %w", err)
+		}
+	}
+
+	app, err := github.NewApp(id, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	owner, repo, err := repository(env)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := github.Options{
+		Timeout: cfg.APITimeout,
+		Retry:   github.RetryOptions{Retries: cfg.Retries},
+	}
+
+	if v, _ := env.lookup("GITHUB_ACTIONS"); v == "true" {
+		opts.Mask = func(secret string) {
+			fmt.Println(actions.Mask(secret))
+		}
+	}
+
+	return github.NewInstallation(app, owner, repo, apiURL(env), opts), nil
+}
+
+func generator(env environment, cfg config.Config, format string) (changelog.Generator, error) {
+	opts := changelog.Options{
+		Rules: cfg.Rules,
+	}
+
+	// offline, without a repository, the changelog has no links
+	if ownerRepo, _ := env.lookup("GITHUB_REPOSITORY"); ownerRepo != "" {
+		owner, repo, err := repository(env)
+		if err != nil {
+			return changelog.Generator{}, err
+		}
+		opts.URLs = github.NewLinks(apiURL(env), owner, repo)
+	}
+
+	var err error
+	if cfg.Template == "" {
+		opts.Renderer, err = changelog.Format(format)
+		return changelog.New(opts), err
+	}
+
+	raw, err := os.ReadFile(cfg.Template)
+	if err != nil {
+		return changelog.Generator{}, fmt.Errorf("release notes template: %w", err)
+	}
+
+	tmpl, err := changelog.ParseTemplate(filepath.Base(cfg.Template), string(raw))
+	if err != nil {
+		return changelog.Generator{}, fmt.Errorf("release notes template: %w", err)
+	}
+	opts.Renderer = changelog.NewTemplate(tmpl)
+
+	return changelog.New(opts), nil
+}
+
+func options(cfg config.Config) versions.Options {
+	return versions.Options{
+		PreRelease: cfg.PreRelease,
+		Rules:      cfg.Rules,
+		Rollback:   cfg.Rollback,
+	}
+}
+
+func nextVersion(ctx context.Context, env environment) error {
+	cfg, local, _, err := setup(ctx, env)
 	if err != nil {
 		return err
 	}
 
-	ownerRepo, err := env("GITHUB_REPOSITORY")
+	res, err := versions.Next(ctx, local, options(cfg))
 	if err != nil {
 		return err
 	}
 
-	chunks := strings.Split(ownerRepo, "/")
-	if len(chunks) != 2 {
-		return fmt.Errorf("invalid owner/repository %q", ownerRepo)
+	fmt.Println(res.Version)
+
+	return nil
+}
+
+func changeLog(ctx context.Context, env environment) error {
+	cfg, local, _, err := setup(ctx, env)
+	if err != nil {
+		return err
+	}
+
+	gen, err := generator(env, cfg, cfg.Format)
+	if err != nil {
+		return err
+	}
+
+	res, err := versions.Next(ctx, local, options(cfg))
+	if err != nil {
+		return err
+	}
+
+	out, err := gen.Render(res.Changes)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(out)
+
+	return nil
+}
+
+type noRelease struct{}
+
+func (noRelease) Release(context.Context, versions.Changes) (versions.Release, error) {
+	return versions.Release{}, nil
+}
+
+func tag(ctx context.Context, env environment) error {
+	cfg, local, _, err := setup(ctx, env)
+	if err != nil {
+		return err
 	}
 
-	token, err := env("GITHUB_TOKEN")
+	if cfg.DryRun {
+		fmt.Println("Dry run, nothing will be pushed")
+		_, err = versions.Process(ctx, local, local.DryRun(), noRelease{}, options(cfg))
+		return err
+	}
+
+	_, err = versions.Process(ctx, local, local, noRelease{}, options(cfg))
+	return err
+}
+
+func release(ctx context.Context, env environment) error {
+	cfg, local, app, err := setup(ctx, env)
 	if err != nil {
 		return err
 	}
 
-	assets, closeAll, err := parseAssets()
+	assets, closeAll, err := parseAssets(cfg.Assets)
 	if err != nil {
 		return err
 	}
 	defer closeAll()
 
-	api := github.New(chunks[0], chunks[1], host, token, assets)
+	gen, err := generator(env, cfg, cfg.Format)
+	if err != nil {
+		return err
+	}
+
+	notes, err := github.ParseNotes(cfg.Notes)
+	if err != nil {
+		return err
+	}
+
+	ref, _ := env.lookup("GITHUB_SHA")
+
+	api, err := apiClient(env, app, github.Options{
+		Ref: ref,
+		Tag: github.TagOptions{
+			Annotated: cfg.Tag.Annotated,
+			Message:   cfg.Tag.Message,
+			Tagger:    cfg.Tag.Tagger,
+		},
+		Assets:       assets,
+		ChangeLog:    gen,
+		Notes:        notes,
+		Draft:        cfg.Draft,
+		Timeout:      cfg.APITimeout,
+		Retry:        github.RetryOptions{Retries: cfg.Retries},
+		PullRequests: cfg.PullRequests,
+		Contributors: cfg.Contributors,
+	})
+	if err != nil {
+		return err
+	}
+
+	var res versions.Result
+	switch {
+	case cfg.DryRun && cfg.Tag.Push == "api":
+		fmt.Println("Dry run, nothing will be pushed nor released")
+		res, err = versions.Process(ctx, api, api.DryRun(), api.DryRun(), options(cfg))
+	case cfg.DryRun:
+		fmt.Println("Dry run, nothing will be pushed nor released")
+		res, err = versions.Process(ctx, api, local.DryRun(), api.DryRun(), options(cfg))
+	case cfg.Tag.Push == "api":
+		res, err = versions.Process(ctx, api, api, api, options(cfg))
+	default:
+		res, err = versions.Process(ctx, api, local, api, options(cfg))
+	}
+	if err != nil {
+		return err
+	}
+
+	if path, _ := env.lookup("GITHUB_STEP_SUMMARY"); path != "" {
+		if err := actions.WriteSummary(path, actions.Summary(res, cfg.DryRun)); err != nil {
+			return fmt.Errorf("step summary: %w", err)
+		}
+	}
 
-	local, err := git.SetupClient()
+	return writeOutputs(env, res)
+}
+
+func lint(ctx context.Context, env environment) error {
+	cfg, local, app, err := setup(ctx, env)
+	if err != nil {
+		return err
+	}
+
+	commits, err := lintCommits(ctx, env, cfg, local, app)
 	if err != nil {
 		return err
 	}
 
-	return versions.Process(api, local, api)
+	violations := versions.Lint(commits)
+
+	annotate, _ := env.lookup("GITHUB_ACTIONS")
+	for _, v := range violations {
+		msg := fmt.Sprintf("%s %q: %v", v.Commit.SHA(), v.Commit.Subject(), v.Reason)
+		if annotate == "true" {
+			fmt.Println(actions.Error("Invalid commit message", msg))
+		} else {
+			_, _ = fmt.Fprintln(os.Stderr, msg)
+		}
+	}
+
+	if len(violations) != 0 {
+		return fmt.Errorf("%d of %d commits do not follow Conventional Commits", len(violations), len(commits))
+	}
+
+	fmt.Printf("%d commits follow Conventional Commits\n", len(commits))
+
+	return nil
+}
+
+func lintCommits(ctx context.Context, env environment, cfg config.Config, local git.Client, app *github.Installation) ([]*versions.Commit, error) {
+	if revisions, _ := env.lookup("LINT_RANGE"); revisions != "" {
+		return local.Commits(ctx, revisions)
+	}
+
+	number, _ := env.lookup("LINT_PULL_REQUEST")
+	if ref, _ := env.lookup("GITHUB_REF"); number == "" && strings.HasPrefix(ref, "refs/pull/") {
+		number = strings.Split(ref, "/")[2]
+	}
+
+	if number == "" {
+		tag, err := local.LatestTag(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		revisions := "HEAD"
+		if tag != "" {
+			revisions = string(tag) + "..HEAD"
+		}
+
+		return local.Commits(ctx, revisions)
+	}
+
+	pr, err := strconv.Atoi(number)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pull request number %q", number)
+	}
+
+	api, err := apiClient(env, app, github.Options{
+		Timeout: cfg.APITimeout,
+		Retry:   github.RetryOptions{Retries: cfg.Retries},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return api.PullRequestCommits(ctx, pr)
+}
+
+func apiURL(env environment) string {
+	if host, _ := env.lookup("GITHUB_API_URL"); host != "" {
+		return host
+	}
+
+	return "https://api.github.com"
 }
 
-func env(name string) (string, error) {
-	if out, ok := os.LookupEnv(name); ok {
-		return out, nil
+func apiClient(env environment, app *github.Installation, opts github.Options) (*github.Client, error) {
+	host := apiURL(env)
+
+	owner, repo, err := repository(env)
+	if err != nil {
+		return nil, err
+	}
+
+	if app != nil {
+		opts.Auth = app
+		return github.New(owner, repo, host, "", opts), nil
 	}
-	return "", fmt.Errorf("environment variable %s not set", name)
+
+	token, err := env.require("GITHUB_TOKEN")
+	if err != nil {
+		return nil, err
+	}
+
+	return github.New(owner, repo, host, token, opts), nil
 }
 
-func parseAssets() ([]github.Asset, func(), error) {
-	assets, err := env("RELEASE_ASSETS")
+func repository(env environment) (string, string, error) {
+	ownerRepo, err := env.require("GITHUB_REPOSITORY")
 	if err != nil {
-		return nil, func() {}, nil
+		return "", "", err
+	}
+
+	chunks := strings.Split(ownerRepo, "/")
+	if len(chunks) != 2 {
+		return "", "", fmt.Errorf("invalid owner/repository %q", ownerRepo)
 	}
 
+	return chunks[0], chunks[1], nil
+}
+
+func writeOutputs(env environment, res versions.Result) error {
+	path, _ := env.lookup("GITHUB_OUTPUT")
+	if path == "" {
+		return nil
+	}
+
+	var releaseID string
+	if res.Release.ID != 0 {
+		releaseID = strconv.FormatInt(res.Release.ID, 10)
+	}
+
+	return actions.WriteOutputs(path, []actions.Output{
+		{Name: "previous-version", Value: res.Previous.String()},
+		{Name: "version", Value: res.Version.String()},
+		{Name: "major", Value: strconv.Itoa(res.Version.Major())},
+		{Name: "minor", Value: strconv.Itoa(res.Version.Minor())},
+		{Name: "patch", Value: strconv.Itoa(res.Version.Patch())},
+		{Name: "bump", Value: res.Bump.String()},
+		{Name: "release-id", Value: releaseID},
+		{Name: "release-url", Value: res.Release.URL},
+		{Name: "changelog", Value: res.Release.ChangeLog},
+	})
+}
+
+func parseAssets(patterns []string) ([]github.Asset, func(), error) {
 	var (
 		out     []github.Asset
 		closers []func() error
 	)
-	for _, pattern := range strings.Split(assets, "\n") {
-		pattern := strings.TrimSpace(pattern)
-		if pattern == "" {
-			continue
-		}
-
+	for _, pattern := range patterns {
 		local, err := filepath.Localize(pattern)
 		if err != nil {
 			return nil, nil, fmt.Errorf("%q: %w", pattern, err)
@@ -0,0 +1,389 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/agukrapo/tagger/changelog"
+	"github.com/agukrapo/tagger/github"
+	"github.com/agukrapo/tagger/versions"
+	"gopkg.in/yaml.v3"
+)
+
+var names = []string{".tagger.yml", ".tagger.yaml", ".tagger.json"}
+
+const (
+	DefaultRetries    = 3
+	DefaultAPITimeout = 5 * time.Minute
+)
+
+type rule struct {
+	Type    string `yaml:"type" json:"type"`
+	Bump    string `yaml:"bump" json:"bump"`
+	Section string `yaml:"section" json:"section"`
+}
+
+type ChangeLog struct {
+	File    string `yaml:"file" json:"file"`
+	Message string `yaml:"message" json:"message"`
+	Author  string `yaml:"author" json:"author"`
+	Format  string `yaml:"format" json:"format"`
+}
+
+type Tag struct {
+	Push      string `yaml:"push" json:"push"`
+	Annotated bool   `yaml:"annotated" json:"annotated"`
+	Message   string `yaml:"message" json:"message"`
+	Tagger    string `yaml:"tagger" json:"tagger"`
+
+	Sign           string `yaml:"sign" json:"sign"`
+	SigningKey     string `yaml:"signing-key" json:"signing-key"`
+	AllowedSigners string `yaml:"allowed-signers" json:"allowed-signers"`
+}
+
+type file struct {
+	PreRelease string    `yaml:"pre-release" json:"pre-release"`
+	Assets     []string  `yaml:"assets" json:"assets"`
+	Rules      []rule    `yaml:"rules" json:"rules"`
+	ChangeLog  ChangeLog `yaml:"changelog" json:"changelog"`
+	Template   string    `yaml:"template" json:"template"`
+	Format     string    `yaml:"format" json:"format"`
+	Notes      string    `yaml:"release-notes" json:"release-notes"`
+	Tag        Tag       `yaml:"tag" json:"tag"`
+	Draft      bool      `yaml:"draft" json:"draft"`
+	Rollback   bool      `yaml:"rollback" json:"rollback"`
+	Retries    *int      `yaml:"retries" json:"retries"`
+	APITimeout string    `yaml:"api-timeout" json:"api-timeout"`
+
+	PullRequests bool `yaml:"pull-requests" json:"pull-requests"`
+	Contributors bool `yaml:"contributors" json:"contributors"`
+}
+
+type Config struct {
+	Path       string
+	Workspace  string
+	PreRelease string
+	Assets     []string
+	Rules      versions.Rules
+	DryRun     bool
+	ChangeLog  ChangeLog
+	Template   string
+	Format     string
+	Notes      string
+	Tag        Tag
+	Draft      bool
+	Rollback   bool
+	Retries    int
+	APITimeout time.Duration
+
+	PullRequests bool
+	Contributors bool
+}
+
+type LookupFunc func(string) (string, bool)
+
+func Load(lookup LookupFunc) (Config, error) {
+	out := Config{
+		Rules:      versions.DefaultRules(),
+		Retries:    DefaultRetries,
+		APITimeout: DefaultAPITimeout,
+	}
+
+	workspace, err := workspace(lookup)
+	if err != nil {
+		return Config{}, err
+	}
+	out.Workspace = workspace
+
+	path, err := discover(workspace, lookup)
+	if err != nil {
+		return Config{}, err
+	}
+
+	if path != "" {
+		if err := out.load(path); err != nil {
+			return Config{}, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	if err := out.override(lookup); err != nil {
+		return Config{}, err
+	}
+
+	if err := out.validate(); err != nil {
+		return Config{}, err
+	}
+
+	for _, path := range []*string{&out.Template, &out.Tag.AllowedSigners} {
+		if *path != "" && !filepath.IsAbs(*path) {
+			*path = filepath.Join(workspace, *path)
+		}
+	}
+
+	return out, nil
+}
+
+func workspace(lookup LookupFunc) (string, error) {
+	if dir, ok := lookup("GITHUB_WORKSPACE"); ok && dir != "" {
+		return dir, nil
+	}
+
+	return os.Getwd()
+}
+
+func discover(workspace string, lookup LookupFunc) (string, error) {
+	if path, ok := lookup("TAGGER_CONFIG"); ok && path != "" {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(workspace, path)
+		}
+		return path, nil
+	}
+
+	for _, name := range names {
+		path := filepath.Join(workspace, name)
+
+		_, err := os.Stat(path)
+		if err == nil {
+			return path, nil
+		}
+
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+	}
+
+	return "", nil
+}
+
+func (c *Config) load(path string) error {
+	raw, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return err
+	}
+
+	var in file
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&in); err != nil {
+			return err
+		}
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(raw))
+		dec.KnownFields(true)
+		if err := dec.Decode(&in); err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+	}
+
+	c.Path = path
+	c.PreRelease = in.PreRelease
+	c.Assets = in.Assets
+	c.ChangeLog = in.ChangeLog
+	c.Template = in.Template
+	c.Format = in.Format
+	c.Notes = in.Notes
+	c.Tag = in.Tag
+	c.Draft = in.Draft
+	c.Rollback = in.Rollback
+	if in.Retries != nil {
+		c.Retries = *in.Retries
+	}
+	if in.APITimeout != "" {
+		v, err := time.ParseDuration(in.APITimeout)
+		if err != nil {
+			return fmt.Errorf("api-timeout: %w", err)
+		}
+		c.APITimeout = v
+	}
+	c.PullRequests = in.PullRequests
+	c.Contributors = in.Contributors
+
+	for i, r := range in.Rules {
+		parsed, err := r.parse()
+		if err != nil {
+			return fmt.Errorf("rules[%d]: %w", i, err)
+		}
+		c.Rules = c.Rules.Set(parsed)
+	}
+
+	return nil
+}
+
+func (r rule) parse() (versions.Rule, error) {
+	bump, err := versions.ParseBump(strings.TrimSpace(r.Bump))
+	if err != nil {
+		return versions.Rule{}, err
+	}
+
+	out := versions.Rule{
+		Type:    strings.TrimSpace(r.Type),
+		Bump:    bump,
+		Section: strings.TrimSpace(r.Section),
+	}
+
+	return out, out.Validate()
+}
+
+func (c *Config) override(lookup LookupFunc) error {
+	if channel, ok := lookup("PRE_RELEASE"); ok && channel != "" {
+		c.PreRelease = channel
+	}
+
+	for name, value := range map[string]*bool{
+		"DRY_RUN":               &c.DryRun,
+		"RELEASE_PULL_REQUESTS": &c.PullRequests,
+		"RELEASE_CONTRIBUTORS":  &c.Contributors,
+		"TAG_ANNOTATED":         &c.Tag.Annotated,
+		"RELEASE_DRAFT":         &c.Draft,
+		"RELEASE_ROLLBACK":      &c.Rollback,
+	} {
+		raw, ok := lookup(name)
+		if !ok || raw == "" {
+			continue
+		}
+
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%s: invalid boolean %q", name, raw)
+		}
+		*value = v
+	}
+
+	for name, value := range map[string]*string{
+		"CHANGELOG_FILE":      &c.ChangeLog.File,
+		"CHANGELOG_MESSAGE":   &c.ChangeLog.Message,
+		"CHANGELOG_AUTHOR":    &c.ChangeLog.Author,
+		"CHANGELOG_FORMAT":    &c.ChangeLog.Format,
+		"RELEASE_TEMPLATE":    &c.Template,
+		"RELEASE_FORMAT":      &c.Format,
+		"RELEASE_NOTES":       &c.Notes,
+		"TAG_PUSH":            &c.Tag.Push,
+		"TAG_MESSAGE":         &c.Tag.Message,
+		"TAG_TAGGER":          &c.Tag.Tagger,
+		"TAG_SIGN":            &c.Tag.Sign,
+		"TAG_SIGNING_KEY":     &c.Tag.SigningKey,
+		"TAG_ALLOWED_SIGNERS": &c.Tag.AllowedSigners,
+	} {
+		if v, ok := lookup(name); ok && v != "" {
+			*value = v
+		}
+	}
+
+	if assets, ok := lookup("RELEASE_ASSETS"); ok && strings.TrimSpace(assets) != "" {
+		c.Assets = nil
+		for _, pattern := range strings.Split(assets, "\n") {
+			if pattern := strings.TrimSpace(pattern); pattern != "" {
+				c.Assets = append(c.Assets, pattern)
+			}
+		}
+	}
+
+	if raw, ok := lookup("API_RETRIES"); ok && raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v < 0 {
+			return fmt.Errorf("API_RETRIES: invalid retries %q", raw)
+		}
+		c.Retries = v
+	}
+
+	if raw, ok := lookup("API_TIMEOUT"); ok && raw != "" {
+		v, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("API_TIMEOUT: %w", err)
+		}
+		c.APITimeout = v
+	}
+
+	rules, _ := lookup("RELEASE_RULES")
+	for _, line := range strings.Split(rules, "\n") {
+		line := strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		typ, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return fmt.Errorf("RELEASE_RULES: invalid rule %q, want type=bump[:section]", line)
+		}
+
+		bump, section, _ := strings.Cut(value, ":")
+
+		parsed, err := rule{Type: typ, Bump: bump, Section: section}.parse()
+		if err != nil {
+			return fmt.Errorf("RELEASE_RULES: rule %q: %w", line, err)
+		}
+		c.Rules = c.Rules.Set(parsed)
+	}
+
+	return nil
+}
+
+func (c *Config) validate() error {
+	if c.ChangeLog.Author != "" {
+		if _, err := mail.ParseAddress(c.ChangeLog.Author); err != nil {
+			return fmt.Errorf("changelog author %q: %w", c.ChangeLog.Author, err)
+		}
+	}
+
+	if c.ChangeLog.File == "" && (c.ChangeLog.Message != "" || c.ChangeLog.Author != "") {
+		return errors.New("changelog message and author need a changelog file")
+	}
+
+	if _, err := changelog.Format(c.Format); err != nil {
+		return fmt.Errorf("format: %w", err)
+	}
+
+	if _, err := changelog.Format(c.ChangeLog.Format); err != nil {
+		return fmt.Errorf("changelog format: %w", err)
+	}
+
+	if _, err := github.ParseNotes(c.Notes); err != nil {
+		return err
+	}
+
+	if c.Retries < 0 {
+		return fmt.Errorf("invalid retries %d", c.Retries)
+	}
+
+	if c.APITimeout < 0 {
+		return fmt.Errorf("invalid api timeout %s", c.APITimeout)
+	}
+
+	switch c.Tag.Push {
+	case "", "git":
+	case "api":
+		if c.ChangeLog.File != "" {
+			return errors.New("changelog file needs the git tag push")
+		}
+		if c.Tag.Sign != "" {
+			return errors.New("signed tags need the git tag push")
+		}
+	default:
+		return fmt.Errorf("invalid tag push %q, want git or api", c.Tag.Push)
+	}
+
+	switch c.Tag.Sign {
+	case "", "gpg", "ssh":
+	default:
+		return fmt.Errorf("invalid tag sign %q, want gpg or ssh", c.Tag.Sign)
+	}
+
+	if c.Tag.Tagger != "" {
+		if _, err := mail.ParseAddress(c.Tag.Tagger); err != nil {
+			return fmt.Errorf("tagger %q: %w", c.Tag.Tagger, err)
+		}
+	}
+
+	return nil
+}
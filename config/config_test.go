@@ -0,0 +1,385 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agukrapo/tagger/versions"
+)
+
+func lookupFrom(env map[string]string) LookupFunc {
+	return func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+		t.Fatalf("writeFile: %v", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		env   map[string]string
+		want  Config
+		error string
+	}{
+		{
+			name: "defaults",
+			want: Config{Rules: versions.DefaultRules(), Retries: DefaultRetries, APITimeout: DefaultAPITimeout},
+		},
+		{
+			name: "yaml",
+			files: map[string]string{
+				".tagger.yml": "pre-release: rc\nassets:\n  - bin/*\nrules:\n  - type: perf\n    bump: patch\n    section: Performance\n",
+			},
+			want: Config{
+				Path:       ".tagger.yml",
+				PreRelease: "rc",
+				Assets:     []string{"bin/*"},
+				Retries:    DefaultRetries,
+				APITimeout: DefaultAPITimeout,
+				Rules:      versions.DefaultRules().Set(versions.Rule{Type: "perf", Bump: versions.PatchBump, Section: "Performance"}),
+			},
+		},
+		{
+			name: "json",
+			files: map[string]string{
+				".tagger.json": `{"rules":[{"type":"deps","bump":"patch"}]}`,
+			},
+			want: Config{
+				Path:       ".tagger.json",
+				Retries:    DefaultRetries,
+				APITimeout: DefaultAPITimeout,
+				Rules:      versions.DefaultRules().Set(versions.Rule{Type: "deps", Bump: versions.PatchBump}),
+			},
+		},
+		{
+			name: "yml before json",
+			files: map[string]string{
+				".tagger.yml":  "pre-release: beta\n",
+				".tagger.json": `{"pre-release":"rc"}`,
+			},
+			want: Config{
+				Path:       ".tagger.yml",
+				PreRelease: "beta",
+				Retries:    DefaultRetries,
+				APITimeout: DefaultAPITimeout,
+				Rules:      versions.DefaultRules(),
+			},
+		},
+		{
+			name: "explicit path",
+			files: map[string]string{
+				".tagger.yml": "pre-release: beta\n",
+				"custom.json": `{"pre-release":"rc"}`,
+			},
+			env: map[string]string{"TAGGER_CONFIG": "custom.json"},
+			want: Config{
+				Path:       "custom.json",
+				PreRelease: "rc",
+				Retries:    DefaultRetries,
+				APITimeout: DefaultAPITimeout,
+				Rules:      versions.DefaultRules(),
+			},
+		},
+		{
+			name: "env overrides file",
+			files: map[string]string{
+				".tagger.yml": "pre-release: rc\nassets: [bin/*]\nrules:\n  - type: perf\n    bump: patch\n",
+			},
+			env: map[string]string{
+				"PRE_RELEASE":    "beta",
+				"RELEASE_ASSETS": "dist/*\n\n out/* ",
+				"RELEASE_RULES":  "perf=minor:Performance\nfix=none",
+			},
+			want: Config{
+				Path:       ".tagger.yml",
+				PreRelease: "beta",
+				Assets:     []string{"dist/*", "out/*"},
+				Retries:    DefaultRetries,
+				APITimeout: DefaultAPITimeout,
+				Rules: versions.Rules{
+					{Type: "feat", Bump: versions.MinorBump, Section: "New features"},
+					{Type: "fix", Bump: versions.NoBump, Section: "Bug fixes"},
+					{Type: "perf", Bump: versions.MinorBump, Section: "Performance"},
+				},
+			},
+		},
+		{
+			name: "empty env keeps file",
+			files: map[string]string{
+				".tagger.yml": "pre-release: rc\nassets: [bin/*]\n",
+			},
+			env: map[string]string{"PRE_RELEASE": "", "RELEASE_ASSETS": "", "RELEASE_RULES": ""},
+			want: Config{
+				Path:       ".tagger.yml",
+				PreRelease: "rc",
+				Assets:     []string{"bin/*"},
+				Retries:    DefaultRetries,
+				APITimeout: DefaultAPITimeout,
+				Rules:      versions.DefaultRules(),
+			},
+		},
+		{
+			name: "dry run",
+			env:  map[string]string{"DRY_RUN": "true"},
+			want: Config{Rules: versions.DefaultRules(), Retries: DefaultRetries, APITimeout: DefaultAPITimeout, DryRun: true},
+		},
+		{
+			name:  "invalid dry run",
+			env:   map[string]string{"DRY_RUN": "maybe"},
+			error: `DRY_RUN: invalid boolean "maybe"`,
+		},
+		{
+			name: "changelog",
+			files: map[string]string{
+				".tagger.yml": "changelog:\n  file: CHANGELOG.md\n  author: Bot <bot@example.com>\n",
+			},
+			env: map[string]string{"CHANGELOG_MESSAGE": "release {version}"},
+			want: Config{
+				Path:       ".tagger.yml",
+				Retries:    DefaultRetries,
+				APITimeout: DefaultAPITimeout,
+				Rules:      versions.DefaultRules(),
+				ChangeLog:  ChangeLog{File: "CHANGELOG.md", Message: "release {version}", Author: "Bot <bot@example.com>"},
+			},
+		},
+		{
+			name:  "template",
+			files: map[string]string{".tagger.yml": "template: .github/notes.tmpl\n"},
+			want: Config{
+				Path:       ".tagger.yml",
+				Retries:    DefaultRetries,
+				APITimeout: DefaultAPITimeout,
+				Rules:      versions.DefaultRules(),
+				Template:   ".github/notes.tmpl",
+			},
+		},
+		{
+			name:  "formats",
+			files: map[string]string{".tagger.yml": "format: text\nchangelog:\n  file: CHANGELOG.md\n  format: keep-a-changelog\n"},
+			env:   map[string]string{"RELEASE_FORMAT": "json"},
+			want: Config{
+				Path:       ".tagger.yml",
+				Retries:    DefaultRetries,
+				APITimeout: DefaultAPITimeout,
+				Rules:      versions.DefaultRules(),
+				Format:     "json",
+				ChangeLog:  ChangeLog{File: "CHANGELOG.md", Format: "keep-a-changelog"},
+			},
+		},
+		{
+			name:  "pull requests",
+			files: map[string]string{".tagger.yml": "pull-requests: true\ncontributors: true\n"},
+			env:   map[string]string{"RELEASE_CONTRIBUTORS": "false"},
+			want: Config{
+				Path:         ".tagger.yml",
+				Retries:      DefaultRetries,
+				APITimeout:   DefaultAPITimeout,
+				Rules:        versions.DefaultRules(),
+				PullRequests: true,
+			},
+		},
+		{
+			name: "release notes",
+			env:  map[string]string{"RELEASE_NOTES": "merge"},
+			want: Config{Rules: versions.DefaultRules(), Retries: DefaultRetries, APITimeout: DefaultAPITimeout, Notes: "merge"},
+		},
+		{
+			name:  "invalid release notes",
+			files: map[string]string{".tagger.yml": "release-notes: both\n"},
+			error: `invalid release notes "both", want conventional, github or merge`,
+		},
+		{
+			name:  "tag",
+			files: map[string]string{".tagger.yml": "tag:\n  push: api\n  annotated: true\n  message: release {version}\n"},
+			env:   map[string]string{"TAG_TAGGER": "Bot <bot@example.com>"},
+			want: Config{
+				Path:       ".tagger.yml",
+				Retries:    DefaultRetries,
+				APITimeout: DefaultAPITimeout,
+				Rules:      versions.DefaultRules(),
+				Tag:        Tag{Push: "api", Annotated: true, Message: "release {version}", Tagger: "Bot <bot@example.com>"},
+			},
+		},
+		{
+			name: "annotated tag env",
+			env:  map[string]string{"TAG_ANNOTATED": "true"},
+			want: Config{
+				Retries:    DefaultRetries,
+				APITimeout: DefaultAPITimeout,
+				Rules:      versions.DefaultRules(),
+				Tag:        Tag{Annotated: true},
+			},
+		},
+		{
+			name:  "signed tag",
+			files: map[string]string{".tagger.yml": "tag:\n  sign: ssh\n  allowed-signers: .github/allowed_signers\n"},
+			env:   map[string]string{"TAG_SIGNING_KEY": "~/.ssh/id_ed25519"},
+			want: Config{
+				Path:       ".tagger.yml",
+				Retries:    DefaultRetries,
+				APITimeout: DefaultAPITimeout,
+				Rules:      versions.DefaultRules(),
+				Tag:        Tag{Sign: "ssh", SigningKey: "~/.ssh/id_ed25519", AllowedSigners: ".github/allowed_signers"},
+			},
+		},
+		{
+			name:  "invalid tag sign",
+			env:   map[string]string{"TAG_SIGN": "x509"},
+			error: `invalid tag sign "x509", want gpg or ssh`,
+		},
+		{
+			name:  "api tag push with signing",
+			env:   map[string]string{"TAG_PUSH": "api", "TAG_SIGN": "gpg"},
+			error: "signed tags need the git tag push",
+		},
+		{
+			name:  "retries",
+			files: map[string]string{".tagger.yml": "retries: 5\n"},
+			env:   map[string]string{"API_RETRIES": "0"},
+			want:  Config{Path: ".tagger.yml", Rules: versions.DefaultRules(), APITimeout: DefaultAPITimeout},
+		},
+		{
+			name:  "api timeout",
+			files: map[string]string{".tagger.yml": "api-timeout: 30s\n"},
+			env:   map[string]string{"API_TIMEOUT": "2m"},
+			want:  Config{Path: ".tagger.yml", Rules: versions.DefaultRules(), Retries: DefaultRetries, APITimeout: 2 * time.Minute},
+		},
+		{
+			name:  "invalid api timeout",
+			files: map[string]string{".tagger.yml": "api-timeout: soon\n"},
+			error: `{dir}/.tagger.yml: api-timeout: time: invalid duration "soon"`,
+		},
+		{
+			name:  "negative api timeout",
+			env:   map[string]string{"API_TIMEOUT": "-1s"},
+			error: "invalid api timeout -1s",
+		},
+		{
+			name:  "invalid retries",
+			env:   map[string]string{"API_RETRIES": "-1"},
+			error: `API_RETRIES: invalid retries "-1"`,
+		},
+		{
+			name: "draft",
+			env:  map[string]string{"RELEASE_DRAFT": "true"},
+			want: Config{Rules: versions.DefaultRules(), Retries: DefaultRetries, APITimeout: DefaultAPITimeout, Draft: true},
+		},
+		{
+			name:  "rollback",
+			files: map[string]string{".tagger.yml": "rollback: true\n"},
+			want:  Config{Path: ".tagger.yml", Rules: versions.DefaultRules(), Retries: DefaultRetries, APITimeout: DefaultAPITimeout, Rollback: true},
+		},
+		{
+			name:  "invalid tag push",
+			env:   map[string]string{"TAG_PUSH": "ssh"},
+			error: `invalid tag push "ssh", want git or api`,
+		},
+		{
+			name:  "api tag push with changelog file",
+			env:   map[string]string{"TAG_PUSH": "api", "CHANGELOG_FILE": "CHANGELOG.md"},
+			error: "changelog file needs the git tag push",
+		},
+		{
+			name:  "invalid format",
+			env:   map[string]string{"CHANGELOG_FORMAT": "html"},
+			error: `changelog format: invalid format "html", want markdown, text, json or keep-a-changelog`,
+		},
+		{
+			name:  "invalid changelog author",
+			env:   map[string]string{"CHANGELOG_FILE": "CHANGELOG.md", "CHANGELOG_AUTHOR": "bot"},
+			error: `changelog author "bot": mail: missing '@' or angle-addr`,
+		},
+		{
+			name:  "changelog author without file",
+			env:   map[string]string{"CHANGELOG_AUTHOR": "Bot <bot@example.com>"},
+			error: "changelog message and author need a changelog file",
+		},
+		{
+			name:  "unknown yaml field",
+			files: map[string]string{".tagger.yml": "prerelease: rc\n"},
+			error: "{dir}/.tagger.yml: yaml: unmarshal errors:\n  line 1: field prerelease not found in type config.file",
+		},
+		{
+			name:  "unknown json field",
+			files: map[string]string{".tagger.json": `{"prerelease":"rc"}`},
+			error: `{dir}/.tagger.json: json: unknown field "prerelease"`,
+		},
+		{
+			name:  "invalid bump",
+			files: map[string]string{".tagger.yml": "rules:\n  - type: perf\n    bump: huge\n"},
+			error: `{dir}/.tagger.yml: rules[0]: invalid bump "huge"`,
+		},
+		{
+			name:  "missing type",
+			files: map[string]string{".tagger.yml": "rules:\n  - bump: patch\n"},
+			error: "{dir}/.tagger.yml: rules[0]: type is required",
+		},
+		{
+			name:  "invalid type",
+			files: map[string]string{".tagger.yml": "rules:\n  - type: perf(x)\n    bump: patch\n"},
+			error: `{dir}/.tagger.yml: rules[0]: invalid type "perf(x)"`,
+		},
+		{
+			name:  "invalid env rule",
+			env:   map[string]string{"RELEASE_RULES": "perf patch"},
+			error: `RELEASE_RULES: invalid rule "perf patch", want type=bump[:section]`,
+		},
+		{
+			name:  "missing explicit path",
+			env:   map[string]string{"TAGGER_CONFIG": "missing.yml"},
+			error: "{dir}/missing.yml: open {dir}/missing.yml: no such file or directory",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				writeFile(t, dir, name, content)
+			}
+
+			env := map[string]string{"GITHUB_WORKSPACE": dir}
+			for k, v := range tt.env {
+				env[k] = v
+			}
+
+			got, err := Load(lookupFrom(env))
+			if tt.error != "" {
+				want := strings.ReplaceAll(tt.error, "{dir}", dir)
+				if err == nil || err.Error() != want {
+					t.Fatalf("Load() err = %v, want %v", err, want)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() err = %v", err)
+			}
+
+			tt.want.Workspace = dir
+			if tt.want.Path != "" {
+				tt.want.Path = filepath.Join(dir, tt.want.Path)
+			}
+			if tt.want.Template != "" {
+				tt.want.Template = filepath.Join(dir, tt.want.Template)
+			}
+			if tt.want.Tag.AllowedSigners != "" {
+				tt.want.Tag.AllowedSigners = filepath.Join(dir, tt.want.Tag.AllowedSigners)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Load() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
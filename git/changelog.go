@@ -0,0 +1,151 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/agukrapo/tagger/changelog"
+	"github.com/agukrapo/tagger/versions"
+)
+
+const (
+	DefaultChangeLogMessage = "chore(release): {version}"
+
+	defaultAuthorName  = "github-actions[bot]"
+	defaultAuthorEmail = "41898282+github-actions[bot]@users.noreply.github.com"
+)
+
+type ChangeLogOptions struct {
+	File    string
+	Message string
+	Author  string
+	Render  func(versions.Changes) (string, error)
+}
+
+func (o ChangeLogOptions) section(changes versions.Changes) (string, error) {
+	if o.Render == nil {
+		return changelog.New(changelog.Options{}).Section(changes)
+	}
+
+	return o.Render(changes)
+}
+
+func (o ChangeLogOptions) message(version versions.Version) string {
+	message := o.Message
+	if message == "" {
+		message = DefaultChangeLogMessage
+	}
+
+	message = strings.ReplaceAll(message, "{version}", version.String())
+
+	return fmt.Sprintf("%s\n\n%s: %s", message, versions.ReleaseFooter, version)
+}
+
+func (o ChangeLogOptions) commands(version versions.Version) [][]string {
+	name, email := defaultAuthorName, defaultAuthorEmail
+	if addr, err := mail.ParseAddress(o.Author); err == nil {
+		name, email = addr.Name, addr.Address
+	}
+
+	return [][]string{
+		{"add", o.File},
+		{"-c", "user.name=" + name, "-c", "user.email=" + email, "commit", "-m", o.message(version)},
+		{"push", "origin", "HEAD"},
+	}
+}
+
+func (c Client) commitChangeLog(ctx context.Context, changes versions.Changes) error {
+	opts := c.opts.ChangeLog
+
+	section, err := opts.section(changes)
+	if err != nil {
+		return fmt.Errorf("changelog: %w", err)
+	}
+
+	path := opts.File
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(c.opts.Workspace, path)
+	}
+
+	written, err := prependFile(path, changes.Version.String(), section)
+	if err != nil {
+		return fmt.Errorf("changelog: %w", err)
+	}
+
+	if !written {
+		fmt.Printf("Changelog %s already has %s\n", opts.File, changes.Version)
+		return nil
+	}
+
+	opts.File = path
+	for _, args := range opts.commands(changes.Version) {
+		if _, err := c.git(ctx, args...); err != nil {
+			return fmt.Errorf("git %s: %w", subcommand(args), err)
+		}
+	}
+
+	return nil
+}
+
+func subcommand(args []string) string {
+	for i := 0; i < len(args); i++ {
+		if args[i] == "-c" {
+			i++
+			continue
+		}
+		return args[i]
+	}
+
+	return ""
+}
+
+// prependFile adds the section unless the file has one for version already, e.g. committed before a rolled back release.
+func prependFile(path, version, section string) (bool, error) {
+	raw, err := os.ReadFile(filepath.Clean(path))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return false, err
+	}
+
+	if hasSection(string(raw), version) {
+		return false, nil
+	}
+
+	return true, os.WriteFile(path, []byte(prepend(string(raw), section)), 0o644) // #nosec G306
+}
+
+// hasSection looks for a "## v1.2 (date)" or "## [v1.2] - date" heading.
+func hasSection(content, version string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		heading, ok := strings.CutPrefix(line, "## ")
+		if !ok {
+			continue
+		}
+
+		if fields := strings.Fields(heading); len(fields) != 0 && strings.Trim(fields[0], "[]") == version {
+			return true
+		}
+	}
+
+	return false
+}
+
+func prepend(content, section string) string {
+	const title = "# Changelog\n"
+
+	if content == "" {
+		return title + "\n" + section
+	}
+
+	if strings.HasPrefix(content, "# ") {
+		heading, rest, _ := strings.Cut(content, "\n")
+		return heading + "\n\n" + section + "\n" + strings.TrimLeft(rest, "\n")
+	}
+
+	return section + "\n" + content
+}
@@ -0,0 +1,117 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/agukrapo/tagger/versions"
+)
+
+func Test_prepend(t *testing.T) {
+	section := "## v1.1 (2025-01-02)\n\n- new\n"
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name: "new file",
+			want: "# Changelog\n\n## v1.1 (2025-01-02)\n\n- new\n",
+		},
+		{
+			name:    "title",
+			content: "# Changes\n\n## v1 (2025-01-01)\n\n- old\n",
+			want:    "# Changes\n\n## v1.1 (2025-01-02)\n\n- new\n\n## v1 (2025-01-01)\n\n- old\n",
+		},
+		{
+			name:    "no title",
+			content: "## v1 (2025-01-01)\n\n- old\n",
+			want:    "## v1.1 (2025-01-02)\n\n- new\n\n## v1 (2025-01-01)\n\n- old\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := prepend(tt.content, section); got != tt.want {
+				t.Errorf("prepend() got = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChangeLogOptions(t *testing.T) {
+	opts := ChangeLogOptions{
+		File:   "CHANGELOG.md",
+		Author: "Release Bot <bot@example.com>",
+		Render: func(changes versions.Changes) (string, error) {
+			return "## v0\n\n- " + changes.Commits[0].Subject() + "\n", nil
+		},
+	}
+
+	changes := versions.Changes{Commits: []*versions.Commit{versions.NewCommit("a", "feat: new")}}
+	if got, err := opts.section(changes); err != nil || got != "## v0\n\n- feat: new\n" {
+		t.Errorf("section() got = %q %v", got, err)
+	}
+
+	want := [][]string{
+		{"add", "CHANGELOG.md"},
+		{"-c", "user.name=Release Bot", "-c", "user.email=bot@example.com", "commit", "-m", "chore(release): v0\n\nTagger-Release: v0"},
+		{"push", "origin", "HEAD"},
+	}
+	if got := opts.commands(versions.Version{}); !reflect.DeepEqual(got, want) {
+		t.Errorf("commands() got = %q, want %q", got, want)
+	}
+
+	opts.Author, opts.Message = "", "release {version} [skip ci]"
+	want[1] = []string{"-c", "user.name=github-actions[bot]", "-c", "user.email=41898282+github-actions[bot]@users.noreply.github.com", "commit", "-m", "release v0 [skip ci]\n\nTagger-Release: v0"}
+	if got := opts.commands(versions.Version{}); !reflect.DeepEqual(got, want) {
+		t.Errorf("commands() got = %q, want %q", got, want)
+	}
+
+	if subcommand(want[1]) != "commit" {
+		t.Errorf("subcommand() got = %q, want commit", subcommand(want[1]))
+	}
+}
+
+func Test_prependFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "CHANGELOG.md")
+
+	for _, tt := range []struct {
+		version string
+		section string
+		written bool
+	}{
+		{version: "v1", section: "## v1 (2025-01-01)\n", written: true},
+		{version: "v1.1", section: "## v1.1 (2025-01-02)\n", written: true},
+		{version: "v1.1", section: "## v1.1 (2025-01-03)\n"},
+	} {
+		written, err := prependFile(path, tt.version, tt.section)
+		if err != nil {
+			t.Fatalf("prependFile() err = %v", err)
+		}
+		if written != tt.written {
+			t.Errorf("prependFile(%s) written = %t, want %t", tt.version, written, tt.written)
+		}
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("os.ReadFile: %v", err)
+	}
+
+	if want := "# Changelog\n\n## v1.1 (2025-01-02)\n\n## v1 (2025-01-01)\n"; string(raw) != want {
+		t.Errorf("prependFile() got = %q, want %q", raw, want)
+	}
+}
+
+func Test_hasSection(t *testing.T) {
+	content := "# Changelog\n\n## [v1.1] - 2025-01-02\n\n- new\n\n## v1 (2025-01-01)\n\n- v1.2 soon\n"
+
+	for version, want := range map[string]bool{"v1": true, "v1.1": true, "v1.2": false, "v0": false} {
+		if got := hasSection(content, version); got != want {
+			t.Errorf("hasSection(%s) = %t, want %t", version, got, want)
+		}
+	}
+}
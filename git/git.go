@@ -1,56 +1,164 @@
 package git
 
 import (
+	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"os"
 	"os/exec"
-	"regexp"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/agukrapo/tagger/versions"
 )
 
-const noTagErr = "fatal: No names found, cannot describe anything."
+type Client struct {
+	opts Options
+}
+
+type Options struct {
+	Workspace   string
+	ChangeLog   ChangeLogOptions
+	Tag         TagOptions
+	Credentials Credentials
+}
+
+// Credentials authenticate the pushes with a token other than the one persisted by actions/checkout.
+type Credentials struct {
+	Server string
+	Token  func(context.Context) (string, error)
+}
+
+func (c Credentials) env(token string) []string {
+	server := c.Server
+	if server == "" {
+		server = "https://github.com"
+	}
+
+	// the same key actions/checkout sets, an empty value drops the persisted header
+	key := "http." + strings.TrimSuffix(server, "/") + "/.extraheader"
+	basic := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + token))
+
+	return []string{
+		"GIT_CONFIG_COUNT=2",
+		"GIT_CONFIG_KEY_0=" + key,
+		"GIT_CONFIG_VALUE_0=",
+		"GIT_CONFIG_KEY_1=" + key,
+		"GIT_CONFIG_VALUE_1=AUTHORIZATION: basic " + basic,
+	}
+}
 
-type Client struct{}
+func New(opts Options) Client {
+	return Client{opts}
+}
+
+func SetupClient(ctx context.Context, opts Options) (Client, error) {
+	workspace := opts.Workspace
+	if workspace == "" {
+		workspace = "/github/workspace"
+	}
 
-func SetupClient() (Client, error) {
-	if _, err := command("git", "config", "--global", "--add", "safe.directory", "/github/workspace"); err != nil {
+	if _, err := command(ctx, "git", "config", "--global", "--add", "safe.directory", workspace); err != nil {
 		return Client{}, fmt.Errorf("git config: %w", err)
 	}
 
-	return Client{}, nil
+	return New(opts), nil
+}
+
+func (c Client) LatestTag(ctx context.Context) (versions.Tag, error) {
+	tags, err := c.Tags(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return versions.Latest(tags), nil
+}
+
+func (Client) Tags(ctx context.Context) ([]versions.Tag, error) {
+	return carried(ctx, "--merged", "HEAD")
 }
 
-func (Client) LatestTag() (versions.Tag, error) {
-	out, err := command("git", "describe", "--tags", "--abbrev=0")
+func (Client) HeadTags(ctx context.Context) ([]versions.Tag, error) {
+	return carried(ctx, "--points-at", "HEAD")
+}
+
+// carried adds to the tags the ones on the release commit tagger pushes right after HEAD with a changelog file,
+// since HEAD stays at the commit the workflow ran on.
+func carried(ctx context.Context, args ...string) ([]versions.Tag, error) {
+	out, err := tags(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	ahead, err := tags(ctx, "--contains", "HEAD", "--no-merged", "HEAD")
 	if err != nil {
-		if strings.HasPrefix(err.Error(), noTagErr) {
-			return "", nil
+		return nil, err
+	}
+
+	for _, tag := range ahead {
+		commits, err := log(ctx, "log", "-z", "--format=%H%n%B", fmt.Sprintf("HEAD..%s", tag))
+		if err != nil {
+			return nil, err
+		}
+
+		if len(commits) == 1 && commits[0].IsRelease() {
+			out = append(out, tag)
 		}
+	}
+
+	return out, nil
+}
 
-		return "", fmt.Errorf("git describe: %w", err)
+func tags(ctx context.Context, args ...string) ([]versions.Tag, error) {
+	out, err := command(ctx, "git", append([]string{"tag"}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("git tag: %w", err)
 	}
 
-	return versions.Tag(strings.TrimSpace(out)), nil
+	var tags []versions.Tag
+	for _, line := range strings.Split(out, "\n") {
+		if line := strings.TrimSpace(line); line != "" {
+			tags = append(tags, versions.Tag(line))
+		}
+	}
+
+	return tags, nil
 }
 
-func (Client) CommitsSince(tag versions.Tag) ([]*versions.Commit, error) {
-	args := []string{"log", "--oneline"}
+func (Client) CommitsSince(ctx context.Context, tag versions.Tag) ([]*versions.Commit, error) {
+	args := []string{"log", "-z", "--format=%H%n%B"}
 
 	if tag != "" {
 		args = slices.Insert(args, 1, fmt.Sprintf("%s..HEAD", tag))
 	}
 
-	commits, err := command("git", args...)
+	return log(ctx, args...)
+}
+
+func (Client) Head(ctx context.Context) (string, error) {
+	out, err := command(ctx, "git", "rev-parse", "HEAD")
 	if err != nil {
-		return nil, err
+		return "", fmt.Errorf("git rev-parse: %w", err)
+	}
+
+	return strings.TrimSpace(out), nil
+}
+
+func (Client) Commits(ctx context.Context, revisions string) ([]*versions.Commit, error) {
+	return log(ctx, "log", "-z", "--no-merges", "--format=%H%n%B", revisions)
+}
+
+func log(ctx context.Context, args ...string) ([]*versions.Commit, error) {
+	commits, err := command(ctx, "git", args...)
+	if err != nil {
+		return nil, fmt.Errorf("git log: %w", err)
 	}
 
 	var out []*versions.Commit
-	for _, line := range strings.Split(commits, "\n") {
-		if commit, ok := parse(line); ok {
+	for _, record := range strings.Split(commits, "\x00") {
+		if commit, ok := parse(record); ok {
 			out = append(out, commit)
 		}
 	}
@@ -58,33 +166,109 @@ func (Client) CommitsSince(tag versions.Tag) ([]*versions.Commit, error) {
 	return out, nil
 }
 
-func parse(line string) (*versions.Commit, bool) {
-	re := regexp.MustCompile(`^(?P<sha>\w+)( \(.+\))? (?P<message>.+)$`)
-
-	matches := re.FindStringSubmatch(line)
-	if len(matches) == 0 {
+func parse(record string) (*versions.Commit, bool) {
+	sha, message, _ := strings.Cut(strings.TrimLeft(record, "\n"), "\n")
+	if sha == "" || message == "" {
 		return nil, false
 	}
 
-	return versions.NewCommit(matches[re.SubexpIndex("sha")], matches[re.SubexpIndex("message")]), true
+	return versions.NewCommit(sha, message), true
 }
 
-func (Client) Push(version versions.Version) error {
-	if _, err := command("git", "tag", version.String()); err != nil {
-		return fmt.Errorf("git tag: %w", err)
+func (c Client) Push(ctx context.Context, changes versions.Changes) error {
+	if c.opts.ChangeLog.File != "" {
+		if err := c.commitChangeLog(ctx, changes); err != nil {
+			return err
+		}
 	}
 
-	if _, err := command("git", "push", "origin", version.String()); err != nil {
-		return fmt.Errorf("git push: %w", err)
+	return c.tag(ctx, changes)
+}
+
+type DryRun struct {
+	client Client
+}
+
+func (c Client) DryRun() DryRun {
+	return DryRun{c}
+}
+
+func (d DryRun) Push(_ context.Context, changes versions.Changes) error {
+	if opts := d.client.opts.ChangeLog; opts.File != "" {
+		section, err := opts.section(changes)
+		if err != nil {
+			return fmt.Errorf("changelog: %w", err)
+		}
+
+		fmt.Printf("[dry-run] prepend to %s:\n%s\n", opts.File, section)
+
+		for _, args := range opts.commands(changes.Version) {
+			fmt.Printf("[dry-run] git %s\n", strings.Join(args, " "))
+		}
+	}
+
+	opts := d.client.opts.Tag
+
+	var message string
+	if opts.annotated() {
+		var err error
+		if message, err = opts.message(changes); err != nil {
+			return fmt.Errorf("tag message: %w", err)
+		}
+	}
+
+	key := opts.SigningKey
+	if strings.Contains(key, "PRIVATE KEY") {
+		key = "<signing key>"
+	}
+
+	for _, args := range opts.commands(changes.Version, message, key) {
+		fmt.Printf("[dry-run] git %s\n", strings.Join(args, " "))
+	}
+
+	if opts.Sign != "" {
+		fmt.Printf("[dry-run] git %s\n", strings.Join(opts.verifyCommand(changes.Version, opts.AllowedSigners), " "))
 	}
 
 	return nil
 }
 
-func command(in string, arg ...string) (string, error) {
-	cmd := exec.Command(in, arg...)
+// git runs a git command, authenticating the ones reaching the remote with the credentials through the environment, keeping the token out of the arguments.
+func (c Client) git(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+
+	if creds := c.opts.Credentials; creds.Token != nil && slices.Contains([]string{"push", "ls-remote", "fetch"}, subcommand(args)) {
+		token, err := creds.Token(ctx)
+		if err != nil {
+			return "", fmt.Errorf("credentials: %w", err)
+		}
+		cmd.Env = append(os.Environ(), creds.env(token)...)
+	}
+
+	return output(ctx, cmd)
+}
+
+func command(ctx context.Context, in string, arg ...string) (string, error) {
+	return commandInput(ctx, "", in, arg...)
+}
+
+func commandInput(ctx context.Context, stdin, in string, arg ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, in, arg...)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+
+	return output(ctx, cmd)
+}
+
+func output(ctx context.Context, cmd *exec.Cmd) (string, error) {
+	cmd.WaitDelay = time.Second // don't wait on pipes held open by children of a killed git
 
 	bytes, err := cmd.Output()
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+
 	if err != nil {
 		var exitErr *exec.ExitError
 		if errors.As(err, &exitErr) {
@@ -0,0 +1,262 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/agukrapo/tagger/versions"
+)
+
+const (
+	SignGPG = "gpg"
+	SignSSH = "ssh"
+)
+
+type TagOptions struct {
+	Annotated bool
+	Message   string
+	Tagger    string
+	Render    func(versions.Changes) (string, error)
+
+	Sign           string
+	SigningKey     string
+	AllowedSigners string
+}
+
+func (o TagOptions) annotated() bool {
+	return o.Annotated || o.Sign != ""
+}
+
+func (o TagOptions) message(changes versions.Changes) (string, error) {
+	if o.Message != "" {
+		return strings.ReplaceAll(o.Message, "{version}", changes.Version.String()), nil
+	}
+
+	if o.Render != nil {
+		out, err := o.Render(changes)
+		if err != nil {
+			return "", err
+		}
+
+		if strings.TrimSpace(out) != "" {
+			return out, nil
+		}
+	}
+
+	return changes.Version.String(), nil
+}
+
+func (o TagOptions) identity() []string {
+	name, email := defaultAuthorName, defaultAuthorEmail
+	if addr, err := mail.ParseAddress(o.Tagger); err == nil {
+		name, email = addr.Name, addr.Address
+	}
+
+	return []string{"-c", "user.name=" + name, "-c", "user.email=" + email}
+}
+
+func (o TagOptions) signing(key string) []string {
+	format := "openpgp"
+	if o.Sign == SignSSH {
+		format = "ssh"
+	}
+
+	out := []string{"-c", "gpg.format=" + format}
+	if key != "" {
+		out = append(out, "-c", "user.signingkey="+key)
+	}
+
+	return out
+}
+
+func (o TagOptions) commands(version versions.Version, message, key string) [][]string {
+	if !o.annotated() {
+		return [][]string{
+			{"tag", version.String()},
+			{"push", "origin", version.String()},
+		}
+	}
+
+	tag := append(o.identity(), "tag", "-a")
+	if o.Sign != "" {
+		tag = append(append(o.identity(), o.signing(key)...), "tag", "-s")
+	}
+	tag = append(tag, "-m", message, version.String())
+
+	return [][]string{
+		tag,
+		{"push", "origin", version.String()},
+	}
+}
+
+func (o TagOptions) verifyCommand(version versions.Version, allowedSigners string) []string {
+	out := o.signing("")
+	if allowedSigners != "" {
+		out = append(out, "-c", "gpg.ssh.allowedSignersFile="+allowedSigners)
+	}
+
+	return append(out, "tag", "-v", version.String())
+}
+
+func (c Client) tag(ctx context.Context, changes versions.Changes) error {
+	opts := c.opts.Tag
+
+	var message, key, dir string
+	if opts.annotated() {
+		var err error
+		if message, err = opts.message(changes); err != nil {
+			return fmt.Errorf("tag message: %w", err)
+		}
+	}
+
+	if opts.Sign != "" {
+		var err error
+		if dir, err = os.MkdirTemp("", "tagger-"); err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+
+		if key, err = opts.signingKey(ctx, dir); err != nil {
+			return fmt.Errorf("signing key: %w", err)
+		}
+	}
+
+	for _, args := range opts.commands(changes.Version, message, key) {
+		if _, err := c.git(ctx, args...); err != nil {
+			return fmt.Errorf("git %s: %w", subcommand(args), err)
+		}
+	}
+
+	if opts.Sign != "" {
+		return c.verify(ctx, changes.Version, key, dir)
+	}
+
+	return nil
+}
+
+func (c Client) DeleteTag(ctx context.Context, changes versions.Changes) error {
+	for _, args := range deleteCommands(changes.Version) {
+		if _, err := c.git(ctx, args...); err != nil {
+			return fmt.Errorf("git %s: %w", subcommand(args), err)
+		}
+	}
+
+	return nil
+}
+
+func (d DryRun) DeleteTag(_ context.Context, changes versions.Changes) error {
+	for _, args := range deleteCommands(changes.Version) {
+		fmt.Printf("[dry-run] git %s\n", strings.Join(args, " "))
+	}
+
+	return nil
+}
+
+func deleteCommands(version versions.Version) [][]string {
+	return [][]string{
+		{"push", "--delete", "origin", version.String()},
+		{"tag", "--delete", version.String()},
+	}
+}
+
+func (c Client) verify(ctx context.Context, version versions.Version, key, dir string) error {
+	opts := c.opts.Tag
+	ref := "refs/tags/" + version.String()
+
+	remote, err := c.git(ctx, "ls-remote", "origin", ref)
+	if err != nil {
+		return fmt.Errorf("git ls-remote: %w", err)
+	}
+
+	local, err := command(ctx, "git", "rev-parse", ref)
+	if err != nil {
+		return fmt.Errorf("git rev-parse: %w", err)
+	}
+
+	if sha, _, _ := strings.Cut(remote, "\t"); sha != strings.TrimSpace(local) {
+		return fmt.Errorf("verify tag %s: pushed tag %q differs from local tag %q", version, sha, strings.TrimSpace(local))
+	}
+
+	allowedSigners := opts.AllowedSigners
+	if opts.Sign == SignSSH && allowedSigners == "" {
+		if allowedSigners, err = opts.allowedSigners(ctx, dir, key); err != nil {
+			return fmt.Errorf("verify tag %s: %w", version, err)
+		}
+	}
+
+	if _, err := command(ctx, "git", opts.verifyCommand(version, allowedSigners)...); err != nil {
+		return fmt.Errorf("verify tag %s: %w", version, err)
+	}
+
+	return nil
+}
+
+// signingKey writes inline private keys where git can use them: a file for SSH, the keyring for GPG.
+func (o TagOptions) signingKey(ctx context.Context, dir string) (string, error) {
+	key := strings.TrimSpace(o.SigningKey)
+
+	switch {
+	case o.Sign == SignSSH && strings.Contains(key, "PRIVATE KEY"):
+		path := filepath.Join(dir, "signing_key")
+		return path, os.WriteFile(path, []byte(key+"\n"), 0o600)
+	case o.Sign == SignGPG && strings.Contains(key, "BEGIN PGP PRIVATE KEY BLOCK"):
+		if _, err := commandInput(ctx, key, "gpg", "--batch", "--import"); err != nil {
+			return "", fmt.Errorf("gpg --import: %w", err)
+		}
+
+		out, err := commandInput(ctx, key, "gpg", "--batch", "--with-colons", "--import-options", "show-only", "--import")
+		if err != nil {
+			return "", fmt.Errorf("gpg --import: %w", err)
+		}
+
+		for _, line := range strings.Split(out, "\n") {
+			if fields := strings.Split(line, ":"); fields[0] == "fpr" && len(fields) > 9 {
+				return fields[9], nil
+			}
+		}
+
+		return "", errors.New("no fingerprint in GPG key")
+	}
+
+	return key, nil
+}
+
+func (o TagOptions) allowedSigners(ctx context.Context, dir, key string) (string, error) {
+	if key == "" {
+		return "", errors.New("an SSH signing key or allowed signers file is required")
+	}
+
+	public, err := publicKey(ctx, key)
+	if err != nil {
+		return "", fmt.Errorf("public key: %w", err)
+	}
+
+	email := defaultAuthorEmail
+	if addr, err := mail.ParseAddress(o.Tagger); err == nil {
+		email = addr.Address
+	}
+
+	path := filepath.Join(dir, "allowed_signers")
+	line := fmt.Sprintf("%s namespaces=\"git\" %s\n", email, strings.TrimSpace(public))
+
+	return path, os.WriteFile(path, []byte(line), 0o600)
+}
+
+func publicKey(ctx context.Context, key string) (string, error) {
+	switch {
+	case strings.HasPrefix(key, "key::"):
+		return strings.TrimPrefix(key, "key::"), nil
+	case strings.HasPrefix(key, "ssh-"):
+		return key, nil
+	case strings.HasSuffix(key, ".pub"):
+		raw, err := os.ReadFile(filepath.Clean(key))
+		return string(raw), err
+	}
+
+	return command(ctx, "ssh-keygen", "-y", "-f", key)
+}
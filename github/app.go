@@ -0,0 +1,152 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type tokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// App signs the JWTs a GitHub App authenticates with.
+type App struct {
+	id  string
+	key *rsa.PrivateKey
+	now func() time.Time
+}
+
+func NewApp(id string, privateKey []byte) (*App, error) {
+	block, _ := pem.Decode(privateKey)
+	if block == nil {
+		return nil, errors.New("app private key: no PEM data")
+	}
+
+	key, err := parseKey(block)
+	if err != nil {
+		return nil, fmt.Errorf("app private key: %w", err)
+	}
+
+	return &App{id: id, key: key, now: time.Now}, nil
+}
+
+func parseKey(block *pem.Block) (*rsa.PrivateKey, error) {
+	if block.Type == "RSA PRIVATE KEY" {
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	out, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported %T key, want RSA", key)
+	}
+
+	return out, nil
+}
+
+// Token returns a JWT valid for a few minutes, backdated to allow for clock drift.
+func (a *App) Token(context.Context) (string, error) {
+	now := a.now()
+
+	claims, err := json.Marshal(map[string]any{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": a.id,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`)) + "." + enc.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, a.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("sign app JWT: %w", err)
+	}
+
+	return unsigned + "." + enc.EncodeToString(signature), nil
+}
+
+// Installation exchanges the app JWT for the token of the app installation on owner/repo, refreshing it before it expires.
+type Installation struct {
+	client *Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+func NewInstallation(app *App, owner, repo, host string, opts Options) *Installation {
+	opts.Auth = app
+
+	return &Installation{client: New(owner, repo, host, "", opts)}
+}
+
+type installationResponse struct {
+	ID int64 `json:"id"`
+}
+
+type accessTokenResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (i *Installation) Token(ctx context.Context) (string, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.token != "" && i.client.now().Add(time.Minute).Before(i.expires) {
+		return i.token, nil
+	}
+
+	req := &request{
+		method: http.MethodGet,
+		name:   "app installation",
+		url:    i.client.url("installation"),
+	}
+
+	var installation installationResponse
+	if err := i.client.send(ctx, req, &installation); err != nil {
+		return "", err
+	}
+
+	req = &request{
+		method: http.MethodPost,
+		name:   "installation token",
+		url:    fmt.Sprintf("%s/app/installations/%d/access_tokens", i.client.host, installation.ID),
+		// an extra token just expires unused
+		idempotent: true,
+		secret:     true,
+	}
+
+	var out accessTokenResponse
+	if err := i.client.send(ctx, req, &out); err != nil {
+		return "", err
+	}
+
+	if i.client.mask != nil {
+		i.client.mask(out.Token)
+	}
+
+	i.token, i.expires = out.Token, out.ExpiresAt
+
+	return i.token, nil
+}
@@ -0,0 +1,153 @@
+package github
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func appKey(t *testing.T) (*rsa.PrivateKey, []byte) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+}
+
+func TestApp_Token(t *testing.T) {
+	key, raw := appKey(t)
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, pemKey := range map[string][]byte{
+		"pkcs1": raw,
+		"pkcs8": pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			app, err := NewApp("42", pemKey)
+			if err != nil {
+				t.Fatalf("NewApp() err = %v", err)
+			}
+			app.now = func() time.Time { return time.Unix(1000, 0) }
+
+			token, err := app.Token(t.Context())
+			if err != nil {
+				t.Fatalf("Token() err = %v", err)
+			}
+
+			parts := strings.Split(token, ".")
+			if len(parts) != 3 {
+				t.Fatalf("Token() got = %q", token)
+			}
+
+			claims, _ := base64.RawURLEncoding.DecodeString(parts[1])
+			if want := `{"exp":1540,"iat":940,"iss":"42"}`; string(claims) != want {
+				t.Errorf("Token() claims = %s, want %s", claims, want)
+			}
+
+			signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+			digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+			if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+				t.Errorf("Token() signature err = %v", err)
+			}
+		})
+	}
+}
+
+func TestNewApp_invalidKey(t *testing.T) {
+	if _, err := NewApp("42", []byte("key")); err == nil || err.Error() != "app private key: no PEM data" {
+		t.Errorf("NewApp() err = %v", err)
+	}
+}
+
+func TestInstallation_Token(t *testing.T) {
+	_, raw := appKey(t)
+
+	app, err := NewApp("42", raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	var got []string
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		auth := req.Header.Get("Authorization")
+		if req.URL.Path == "/repos/o/r/tags" {
+			got = append(got, req.URL.Path+" "+auth)
+			_, _ = w.Write([]byte(`[]`))
+			return
+		}
+
+		got = append(got, req.Method+" "+req.URL.Path)
+		if strings.Count(auth, ".") != 2 {
+			t.Errorf("%s Authorization = %q, want a JWT", req.URL.Path, auth)
+		}
+
+		switch req.URL.Path {
+		case "/repos/o/r/installation":
+			_, _ = w.Write([]byte(`{"id":7}`))
+		case "/app/installations/7/access_tokens":
+			expires, _ := json.Marshal(now.Add(time.Hour))
+			w.WriteHeader(http.StatusCreated)
+			_, _ = fmt.Fprintf(w, `{"token":"ghs_%d","expires_at":%s}`, len(got), expires)
+		default:
+			t.Errorf("unexpected request %s", req.URL)
+		}
+	}))
+	defer svr.Close()
+
+	var masked []string
+	installation := NewInstallation(app, "o", "r", svr.URL, Options{Mask: func(secret string) {
+		masked = append(masked, secret)
+	}})
+	installation.client.now = func() time.Time { return now }
+
+	c := New("o", "r", svr.URL, "", Options{Auth: installation})
+
+	for _, at := range []time.Time{now, now.Add(50 * time.Minute), now.Add(59*time.Minute + 30*time.Second)} {
+		now = at
+		if _, err := c.Tags(t.Context()); err != nil {
+			t.Fatalf("Tags() err = %v", err)
+		}
+	}
+
+	want := []string{
+		"GET /repos/o/r/installation",
+		"POST /app/installations/7/access_tokens",
+		"/repos/o/r/tags Bearer ghs_2",
+		"/repos/o/r/tags Bearer ghs_2",
+		"GET /repos/o/r/installation",
+		"POST /app/installations/7/access_tokens",
+		"/repos/o/r/tags Bearer ghs_6",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("requests = %q, want %q", got, want)
+	}
+
+	if want := []string{"ghs_2", "ghs_6"}; !reflect.DeepEqual(masked, want) {
+		t.Errorf("masked = %q, want %q", masked, want)
+	}
+
+	if debug := strings.Join(installation.client.debugInfo, "\n"); strings.Contains(debug, "ghs_") {
+		t.Errorf("debug info leaks the token: %s", debug)
+	}
+}
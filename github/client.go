@@ -1,40 +1,178 @@
 package github
 
 import (
+	"cmp"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptrace"
+	neturl "net/url"
 	"strings"
+	"sync/atomic"
+	"time"
 
+	"github.com/agukrapo/tagger/changelog"
 	"github.com/agukrapo/tagger/versions"
 )
 
+type renderer interface {
+	Render(versions.Changes) (string, error)
+}
+
 type Client struct {
 	client *http.Client
 
 	owner, repo, host, token string
+	auth                     tokenSource
+
+	assets    []Asset
+	changeLog renderer
 
-	assets []Asset
+	ref                        string
+	tag                        TagOptions
+	notes                      Notes
+	draft                      bool
+	pullRequests, contributors bool
 
+	timeout time.Duration
+	retry   RetryOptions
+	sleep   func(context.Context, time.Duration) error
+	now     func() time.Time
+
+	mask      func(string)
 	debugInfo []string
 }
 
-func New(owner, repo, host, token string, assets []Asset) *Client {
-	return &Client{
-		client: http.DefaultClient,
-		owner:  owner,
-		repo:   repo,
-		host:   host,
-		token:  token,
-		assets: assets,
+type Notes uint8
+
+const (
+	ConventionalNotes Notes = iota
+	GeneratedNotes
+	MergedNotes
+)
+
+func (n Notes) String() string {
+	return [...]string{"conventional", "github", "merge"}[n]
+}
+
+func ParseNotes(in string) (Notes, error) {
+	if in == "" {
+		return ConventionalNotes, nil
+	}
+
+	for _, n := range []Notes{ConventionalNotes, GeneratedNotes, MergedNotes} {
+		if strings.EqualFold(in, n.String()) {
+			return n, nil
+		}
 	}
+
+	return ConventionalNotes, fmt.Errorf("invalid release notes %q, want conventional, github or merge", in)
+}
+
+type Options struct {
+	Ref       string
+	Tag       TagOptions
+	Assets    []Asset
+	ChangeLog renderer
+	Notes     Notes
+	Draft     bool
+	Auth      tokenSource
+	Timeout   time.Duration
+	Retry     RetryOptions
+	// Mask hides the credentials the client obtains from the logs, e.g. the installation token.
+	Mask func(secret string)
+
+	PullRequests bool
+	Contributors bool
+}
+
+func New(owner, repo, host, token string, opts Options) *Client {
+	out := &Client{
+		client:    http.DefaultClient,
+		owner:     owner,
+		repo:      repo,
+		host:      host,
+		token:     token,
+		auth:      opts.Auth,
+		assets:    opts.Assets,
+		ref:       opts.Ref,
+		tag:       opts.Tag,
+		changeLog: opts.ChangeLog,
+		notes:     opts.Notes,
+		draft:     opts.Draft,
+		timeout:   opts.Timeout,
+		retry:     opts.Retry,
+		sleep:     sleep,
+		now:       time.Now,
+		mask:      opts.Mask,
+
+		pullRequests: opts.PullRequests,
+		contributors: opts.Contributors,
+	}
+
+	if out.ref == "" {
+		out.ref = "HEAD"
+	}
+
+	if out.changeLog == nil {
+		out.changeLog = changelog.New(changelog.Options{URLs: out.Links()})
+	}
+
+	return out
+}
+
+func (c *Client) authorization(ctx context.Context) (string, error) {
+	if c.auth == nil {
+		return c.token, nil
+	}
+
+	return c.auth.Token(ctx)
 }
 
 func (c *Client) url(path string) string {
 	return fmt.Sprintf("%s/repos/%s/%s/%s", c.host, c.owner, c.repo, path)
 }
 
+type Links struct {
+	server, owner, repo string
+}
+
+func NewLinks(host, owner, repo string) Links {
+	host = strings.TrimSuffix(host, "/")
+
+	server := "https://github.com"
+	switch {
+	case host == "" || host == "https://api.github.com":
+	case strings.HasSuffix(host, "/api/v3"):
+		server = strings.TrimSuffix(host, "/api/v3")
+	case strings.Contains(host, "://api."):
+		server = strings.Replace(host, "://api.", "://", 1)
+	default:
+		server = host
+	}
+
+	return Links{server: server, owner: owner, repo: repo}
+}
+
+func (c *Client) Links() Links {
+	return NewLinks(c.host, c.owner, c.repo)
+}
+
+func (l Links) Commit(sha string) string {
+	return fmt.Sprintf("%s/%s/%s/commit/%s", l.server, l.owner, l.repo, sha)
+}
+
+func (l Links) Compare(base, head string) string {
+	return fmt.Sprintf("%s/%s/%s/compare/%s...%s", l.server, l.owner, l.repo, base, head)
+}
+
+func (l Links) PullRequest(number int) string {
+	return fmt.Sprintf("%s/%s/%s/pull/%d", l.server, l.owner, l.repo, number)
+}
+
 type request struct {
 	method     string
 	reader     io.Reader
@@ -42,58 +180,330 @@ type request struct {
 	name, body string
 	headers    map[string]string
 	url        string
+	// idempotent marks POSTs that can be sent twice harmlessly.
+	idempotent bool
+	// created tells whether a POST that failed after reaching the server took effect anyway, filling out when it did.
+	created func(ctx context.Context, out any) (bool, error)
+	// secret keeps the response body, carrying credentials, out of the debug info.
+	secret bool
+}
+
+// resend tells whether the request can be sent again after a failure the server may have acted on.
+func (r *request) resend() bool {
+	return r.method != http.MethodPost || r.idempotent || r.created != nil
 }
 
 type tagsResponse []struct {
-	Name string `json:"name"`
+	Name   string `json:"name"`
+	Commit struct {
+		SHA string `json:"sha"`
+	} `json:"commit"`
+}
+
+func (c *Client) LatestTag(ctx context.Context) (versions.Tag, error) {
+	tags, err := c.Tags(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return versions.Latest(tags), nil
+}
+
+// Tags returns the version tags merged into the ref, like git tag --merged does, down to the latest one the ref doesn't carry.
+func (c *Client) Tags(ctx context.Context) ([]versions.Tag, error) {
+	merged, _, err := c.scan(ctx)
+	return merged, err
 }
 
-func (c *Client) LatestTag() (versions.Tag, error) {
+// HeadTags returns the version tags the ref carries, directly or on the release commit tagger pushes on top of it.
+func (c *Client) HeadTags(ctx context.Context) ([]versions.Tag, error) {
+	_, head, err := c.scan(ctx)
+	return head, err
+}
+
+// scan walks the version tags newest first, comparing each to the ref until it finds one merged into the ref that the ref doesn't carry.
+// Older tags aren't needed to compute the next version and each one would cost a compare request.
+func (c *Client) scan(ctx context.Context) (merged, head []versions.Tag, err error) {
+	tags, err := c.tags(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	names := make([]versions.Tag, len(tags))
+	commits := make(map[versions.Tag]string, len(tags))
+	for i, t := range tags {
+		names[i] = versions.Tag(t.Name)
+		commits[names[i]] = t.Commit.SHA
+	}
+
+	relations := make(map[string]relation)
+	for _, tag := range versions.Newest(names) {
+		key := cmp.Or(commits[tag], string(tag))
+		rel, found := relations[key]
+		if !found {
+			if rel, err = c.relation(ctx, tag); err != nil {
+				return nil, nil, err
+			}
+			relations[key] = rel
+		}
+
+		switch rel {
+		case carried:
+			merged, head = append(merged, tag), append(head, tag)
+		case ancestor:
+			return append(merged, tag), head, nil
+		}
+	}
+
+	return merged, head, nil
+}
+
+type relation int
+
+const (
+	unrelated relation = iota
+	ancestor
+	carried
+)
+
+type compareStatusResponse struct {
+	Status  string           `json:"status"`
+	AheadBy int              `json:"ahead_by"`
+	Commits []commitResponse `json:"commits"`
+}
+
+// relation compares the tag to the ref: tags of other branches are unrelated,
+// and the ref carries both its own tags and the ones on the release commit tagger adds after it with a changelog file.
+func (c *Client) relation(ctx context.Context, tag versions.Tag) (relation, error) {
 	req := &request{
 		method: http.MethodGet,
-		name:   "tags",
-		url:    c.url("tags"),
+		name:   "compare",
+		url:    c.url(fmt.Sprintf("compare/%s...%s?per_page=1", c.ref, tag)),
 	}
 
-	var tags tagsResponse
-	if err := c.send(req, &tags); err != nil {
-		return "", err
+	var out compareStatusResponse
+	if err := c.send(ctx, req, &out); err != nil {
+		return unrelated, err
 	}
 
-	for _, t := range tags {
-		if tag := versions.Tag(t.Name); tag.Valid() {
-			return tag, nil
+	switch {
+	case out.Status == "behind":
+		return ancestor, nil
+	case out.Status == "identical":
+		return carried, nil
+	case out.Status == "ahead" && out.AheadBy == 1 && len(out.Commits) == 1 && versions.NewCommit(out.Commits[0].SHA, out.Commits[0].Data.Message).IsRelease():
+		return carried, nil
+	}
+
+	return unrelated, nil
+}
+
+func (c *Client) tags(ctx context.Context) (tagsResponse, error) {
+	var out tagsResponse
+
+	for url := c.url("tags?per_page=100"); url != ""; {
+		req := &request{
+			method: http.MethodGet,
+			name:   "tags",
+			url:    url,
+		}
+
+		var tags tagsResponse
+		next, err := c.sendPage(ctx, req, &tags)
+		if err != nil {
+			return nil, err
 		}
+
+		out = append(out, tags...)
+		url = next
 	}
 
-	return "", nil
+	return out, nil
+}
+
+type commitResponse struct {
+	SHA  string `json:"sha"`
+	Data struct {
+		Message string `json:"message"`
+	} `json:"commit"`
+	Author *struct {
+		Login string `json:"login"`
+	} `json:"author"`
+	Parents []struct {
+		SHA string `json:"sha"`
+	} `json:"parents"`
 }
 
 type compareResponse struct {
-	Commits []struct {
-		SHA  string `json:"sha"`
-		Data struct {
-			Message string `json:"message"`
-		} `json:"commit"`
-	} `json:"commits"`
+	Commits []commitResponse `json:"commits"`
+}
+
+func (c *Client) CommitsSince(ctx context.Context, tag versions.Tag) ([]*versions.Commit, error) {
+	var out []*versions.Commit
+
+	for url := c.url(fmt.Sprintf("compare/%s...%s?per_page=100", tag, c.ref)); url != ""; {
+		req := &request{
+			method: http.MethodGet,
+			name:   "compare",
+			url:    url,
+		}
+
+		var payload compareResponse
+		next, err := c.sendPage(ctx, req, &payload)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, commit := range payload.Commits {
+			parsed := versions.NewCommit(commit.SHA, commit.Data.Message)
+			if (c.pullRequests || c.contributors) && commit.Author != nil {
+				parsed.SetAuthor(commit.Author.Login)
+			}
+			out = append(out, parsed)
+		}
+
+		url = next
+	}
+
+	if err := c.enrich(ctx, tag, out); err != nil {
+		return nil, err
+	}
+
+	return out, nil
 }
 
-func (c *Client) CommitsSince(tag versions.Tag) ([]*versions.Commit, error) {
+func (c *Client) Head(ctx context.Context) (string, error) {
 	req := &request{
 		method: http.MethodGet,
-		name:   "compare",
-		url:    c.url(fmt.Sprintf("compare/%s...HEAD", tag)),
+		name:   "head",
+		url:    c.url("commits/" + c.ref),
 	}
 
-	var payload compareResponse
-	if err := c.send(req, &payload); err != nil {
-		return nil, err
+	var out commitResponse
+	return out.SHA, c.send(ctx, req, &out)
+}
+
+type pullResponse struct {
+	Number   int    `json:"number"`
+	Title    string `json:"title"`
+	MergedAt string `json:"merged_at"`
+	User     struct {
+		Login string `json:"login"`
+	} `json:"user"`
+}
+
+func (c *Client) enrich(ctx context.Context, tag versions.Tag, commits []*versions.Commit) error {
+	if c.pullRequests {
+		for _, commit := range commits {
+			pr, err := c.pullRequest(ctx, commit.SHA())
+			if err != nil {
+				return err
+			}
+
+			if pr.Number == 0 {
+				continue
+			}
+
+			commit.SetPullRequest(versions.PullRequest{Number: pr.Number, Title: pr.Title})
+			if commit.Author() == "" {
+				commit.SetAuthor(pr.User.Login)
+			}
+		}
+	}
+
+	if !c.contributors {
+		return nil
+	}
+
+	first := make(map[string]bool)
+	for _, commit := range commits {
+		login := commit.Author()
+		if login == "" {
+			continue
+		}
+
+		if _, ok := first[login]; !ok {
+			v, err := c.firstContribution(ctx, tag, login)
+			if err != nil {
+				return err
+			}
+			first[login] = v
+		}
+
+		commit.SetFirstContribution(first[login])
+	}
+
+	return nil
+}
+
+func (c *Client) pullRequest(ctx context.Context, sha string) (pullResponse, error) {
+	req := &request{
+		method: http.MethodGet,
+		name:   "commit pull requests",
+		url:    c.url(fmt.Sprintf("commits/%s/pulls", sha)),
+	}
+
+	var pulls []pullResponse
+	if err := c.send(ctx, req, &pulls); err != nil {
+		return pullResponse{}, err
+	}
+
+	for _, pr := range pulls {
+		if pr.MergedAt != "" {
+			return pr, nil
+		}
+	}
+
+	if len(pulls) != 0 {
+		return pulls[0], nil
 	}
 
-	out := make([]*versions.Commit, 0, len(payload.Commits))
-	for _, commit := range payload.Commits {
-		chunks := strings.Split(commit.Data.Message, "\n")
-		out = append(out, versions.NewCommit(commit.SHA, strings.TrimSpace(chunks[0])))
+	return pullResponse{}, nil
+}
+
+func (c *Client) firstContribution(ctx context.Context, tag versions.Tag, login string) (bool, error) {
+	if tag == "" {
+		return true, nil
+	}
+
+	req := &request{
+		method: http.MethodGet,
+		name:   "author commits",
+		url:    c.url(fmt.Sprintf("commits?author=%s&sha=%s&per_page=1", neturl.QueryEscape(login), neturl.QueryEscape(string(tag)))),
+	}
+
+	var commits []commitResponse
+	if err := c.send(ctx, req, &commits); err != nil {
+		return false, err
+	}
+
+	return len(commits) == 0, nil
+}
+
+func (c *Client) PullRequestCommits(ctx context.Context, number int) ([]*versions.Commit, error) {
+	var out []*versions.Commit
+
+	for url := c.url(fmt.Sprintf("pulls/%d/commits?per_page=100", number)); url != ""; {
+		req := &request{
+			method: http.MethodGet,
+			name:   "pull request commits",
+			url:    url,
+		}
+
+		var payload []commitResponse
+		next, err := c.sendPage(ctx, req, &payload)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, commit := range payload {
+			if len(commit.Parents) > 1 {
+				continue
+			}
+			out = append(out, versions.NewCommit(commit.SHA, commit.Data.Message))
+		}
+
+		url = next
 	}
 
 	return out, nil
@@ -113,27 +523,217 @@ func NewAsset(name string, data io.Reader, size int64) Asset {
 	}
 }
 
-func (c *Client) Release(version versions.Version, commits []*versions.Commit) error {
-	uploadURL, err := c.createRelease(version, commits)
+func (c *Client) Release(ctx context.Context, changes versions.Changes) (versions.Release, error) {
+	if changes.Tagged {
+		existing, err := c.findRelease(ctx, changes.Version)
+		if err != nil {
+			return versions.Release{}, err
+		}
+
+		if existing != nil {
+			return c.reconcile(ctx, existing)
+		}
+	}
+
+	changeLog, err := c.releaseNotes(ctx, changes)
 	if err != nil {
-		return err
+		return versions.Release{}, err
+	}
+
+	res, err := c.createRelease(ctx, changes.Version, changeLog)
+	if err != nil {
+		return versions.Release{}, err
+	}
+
+	out := versions.Release{
+		ID:        res.ID,
+		URL:       res.HTMLURL,
+		ChangeLog: changeLog,
+		Draft:     true,
 	}
 
 	for _, asset := range c.assets {
-		if err := c.uploadAsset(uploadURL, asset); err != nil {
-			return err
+		if err := c.uploadAsset(ctx, &res, asset); err != nil {
+			return versions.Release{}, c.discardDraft(ctx, res.ID, err)
 		}
+		out.Assets = append(out.Assets, versions.ReleaseAsset{Name: asset.name, Size: asset.size})
 	}
 
-	return nil
+	if c.draft {
+		return out, nil
+	}
+
+	published, err := c.publishRelease(ctx, res.ID)
+	if err != nil {
+		return out, err
+	}
+
+	out.URL, out.Draft = published.HTMLURL, false
+
+	return out, nil
+}
+
+// reconcile completes a release left behind by a previous run: it uploads the missing assets and publishes it.
+func (c *Client) reconcile(ctx context.Context, existing *releaseResponse) (versions.Release, error) {
+	fmt.Printf("Release %s exists, uploading missing assets\n", existing.TagName)
+
+	out := versions.Release{
+		ID:        existing.ID,
+		URL:       existing.HTMLURL,
+		ChangeLog: existing.Body,
+		Draft:     existing.Draft,
+	}
+
+	for _, asset := range c.assets {
+		id, uploaded := existing.asset(asset.name)
+
+		if id != 0 && !uploaded {
+			if err := c.deleteAsset(ctx, id); err != nil {
+				return out, err
+			}
+		}
+
+		if !uploaded {
+			if err := c.uploadAsset(ctx, existing, asset); err != nil {
+				return out, err
+			}
+		}
+		out.Assets = append(out.Assets, versions.ReleaseAsset{Name: asset.name, Size: asset.size})
+	}
+
+	if !existing.Draft || c.draft {
+		return out, nil
+	}
+
+	published, err := c.publishRelease(ctx, existing.ID)
+	if err != nil {
+		return out, err
+	}
+
+	out.URL, out.Draft = published.HTMLURL, false
+
+	return out, nil
+}
+
+func (c *Client) discardDraft(ctx context.Context, id int64, cause error) error {
+	if err := c.deleteRelease(context.WithoutCancel(ctx), id); err != nil {
+		return errors.Join(cause, fmt.Errorf("delete draft release %d: %w", id, err))
+	}
+
+	return fmt.Errorf("%w, draft release %d deleted", cause, id)
+}
+
+func (c *Client) releaseNotes(ctx context.Context, changes versions.Changes) (string, error) {
+	var conventional, generated string
+
+	if c.notes != GeneratedNotes {
+		var err error
+		if conventional, err = c.changeLog.Render(changes); err != nil {
+			return "", err
+		}
+	}
+
+	if c.notes != ConventionalNotes {
+		var err error
+		if generated, err = c.generateNotes(ctx, changes); err != nil {
+			return "", err
+		}
+	}
+
+	return merge(conventional, generated), nil
+}
+
+func merge(conventional, generated string) string {
+	if conventional == "" || generated == "" {
+		return conventional + generated
+	}
+
+	return strings.TrimRight(conventional, "\n") + "\n\n" + generated
+}
+
+type notesResponse struct {
+	Body string `json:"body"`
+}
+
+func generateNotesBody(changes versions.Changes) string {
+	if changes.Tag == "" {
+		return fmt.Sprintf(`{"tag_name":%q}`, changes.Version)
+	}
+
+	return fmt.Sprintf(`{"tag_name":%q,"previous_tag_name":%q}`, changes.Version, changes.Tag)
+}
+
+func (c *Client) generateNotes(ctx context.Context, changes versions.Changes) (string, error) {
+	body := generateNotesBody(changes)
+
+	req := &request{
+		method: http.MethodPost,
+		reader: strings.NewReader(body),
+		name:   "generate notes",
+		body:   body,
+		url:    c.url("releases/generate-notes"),
+		// generating notes has no side effects
+		idempotent: true,
+	}
+
+	var out notesResponse
+	return out.Body, c.send(ctx, req, &out)
 }
 
 type releaseResponse struct {
-	UploadURL string `json:"upload_url"`
+	ID        int64           `json:"id"`
+	HTMLURL   string          `json:"html_url"`
+	UploadURL string          `json:"upload_url"`
+	TagName   string          `json:"tag_name"`
+	Body      string          `json:"body"`
+	Draft     bool            `json:"draft"`
+	Assets    []assetResponse `json:"assets"`
 }
 
-func (c *Client) createRelease(version versions.Version, commits []*versions.Commit) (string, error) {
-	body := fmt.Sprintf(`{"tag_name":%q,"name":%q,"body":%q}`, version, version, c.changeLog(commits))
+// asset returns the id of the attached asset and whether its upload completed.
+func (r *releaseResponse) asset(name string) (int64, bool) {
+	for _, asset := range r.Assets {
+		if asset.Name == name {
+			return asset.ID, asset.State == "uploaded"
+		}
+	}
+
+	return 0, false
+}
+
+// findRelease lists the releases instead of getting them by tag, which doesn't return drafts.
+func (c *Client) findRelease(ctx context.Context, version versions.Version) (*releaseResponse, error) {
+	for url := c.url("releases?per_page=100"); url != ""; {
+		req := &request{
+			method: http.MethodGet,
+			name:   "releases",
+			url:    url,
+		}
+
+		var releases []releaseResponse
+		next, err := c.sendPage(ctx, req, &releases)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range releases {
+			if releases[i].TagName == version.String() {
+				return &releases[i], nil
+			}
+		}
+
+		url = next
+	}
+
+	return nil, nil
+}
+
+func releaseBody(version versions.Version, changeLog string) string {
+	return fmt.Sprintf(`{"tag_name":%q,"name":%q,"body":%q,"prerelease":%t,"draft":true}`, version, version, changeLog, version.IsPreRelease())
+}
+
+func (c *Client) createRelease(ctx context.Context, version versions.Version, changeLog string) (releaseResponse, error) {
+	body := releaseBody(version, changeLog)
 
 	req := &request{
 		method: http.MethodPost,
@@ -141,47 +741,139 @@ func (c *Client) createRelease(version versions.Version, commits []*versions.Com
 		name:   "releases",
 		body:   body,
 		url:    c.url("releases"),
+		created: func(ctx context.Context, out any) (bool, error) {
+			existing, err := c.findRelease(ctx, version)
+			if err != nil || existing == nil {
+				return false, err
+			}
+
+			*out.(*releaseResponse) = *existing
+			return true, nil
+		},
+	}
+
+	var out releaseResponse
+	return out, c.send(ctx, req, &out)
+}
+
+const publishBody = `{"draft":false}`
+
+func (c *Client) publishRelease(ctx context.Context, id int64) (releaseResponse, error) {
+	req := &request{
+		method: http.MethodPatch,
+		reader: strings.NewReader(publishBody),
+		name:   "publish release",
+		body:   publishBody,
+		url:    c.url(fmt.Sprintf("releases/%d", id)),
 	}
 
 	var out releaseResponse
-	return out.UploadURL, c.send(req, &out)
+	return out, c.send(ctx, req, &out)
+}
+
+func (c *Client) deleteRelease(ctx context.Context, id int64) error {
+	req := &request{
+		method: http.MethodDelete,
+		name:   "delete release",
+		url:    c.url(fmt.Sprintf("releases/%d", id)),
+	}
+
+	return c.send(ctx, req, nil)
+}
+
+func (c *Client) DeleteDraft(ctx context.Context, changes versions.Changes) error {
+	existing, err := c.findRelease(ctx, changes.Version)
+	if err != nil || existing == nil || !existing.Draft {
+		return err
+	}
+
+	return c.deleteRelease(ctx, existing.ID)
+}
+
+func (c *Client) deleteAsset(ctx context.Context, id int64) error {
+	req := &request{
+		method: http.MethodDelete,
+		name:   "delete asset",
+		url:    c.url(fmt.Sprintf("releases/assets/%d", id)),
+	}
+
+	return c.send(ctx, req, nil)
 }
 
-func (c *Client) changeLog(commits []*versions.Commit) string {
-	var (
-		breaking string
-		feat     string
-		fix      string
-		other    string
-	)
+type DryRun struct {
+	client *Client
+}
+
+func (c *Client) DryRun() DryRun {
+	return DryRun{c}
+}
 
-	appendTo := func(section, title, msg, sha string) string {
-		if section == "" {
-			section = fmt.Sprintf("#### %s:\n", title)
+func (d DryRun) Release(ctx context.Context, changes versions.Changes) (versions.Release, error) {
+	if changes.Tagged {
+		existing, err := d.client.findRelease(ctx, changes.Version)
+		if err != nil {
+			return versions.Release{}, err
+		}
+
+		if existing != nil {
+			return d.reconcile(existing), nil
 		}
-		url := fmt.Sprintf("https://github.com/%s/%s/commit/%s", c.owner, c.repo, sha)
-		return section + fmt.Sprintf("- [%s](%s)\n", msg, url)
 	}
 
-	for _, commit := range commits {
-		change, msg := commit.Change()
-		switch change {
-		case versions.Breaking:
-			breaking = appendTo(breaking, "Breaking changes", msg, commit.SHA())
-		case versions.Feat:
-			feat = appendTo(feat, "New features", msg, commit.SHA())
-		case versions.Fix:
-			fix = appendTo(fix, "Bug fixes", msg, commit.SHA())
-		case versions.None:
-			other = appendTo(other, "Other", msg, commit.SHA())
+	var changeLog string
+	if d.client.notes != GeneratedNotes {
+		var err error
+		if changeLog, err = d.client.changeLog.Render(changes); err != nil {
+			return versions.Release{}, err
 		}
 	}
 
-	return breaking + feat + fix + other
+	if d.client.notes != ConventionalNotes {
+		fmt.Printf("[dry-run] POST %s\n%s\n", d.client.url("releases/generate-notes"), generateNotesBody(changes))
+		changeLog = merge(changeLog, "<generated by GitHub>\n")
+	}
+
+	fmt.Printf("Changelog:\n%s\n", changeLog)
+
+	fmt.Printf("[dry-run] POST %s\n%s\n", d.client.url("releases"), releaseBody(changes.Version, changeLog))
+
+	out := versions.Release{ChangeLog: changeLog, Draft: d.client.draft}
+	for _, asset := range d.client.assets {
+		fmt.Printf("[dry-run] upload %s (%d bytes)\n", asset.name, asset.size)
+		out.Assets = append(out.Assets, versions.ReleaseAsset{Name: asset.name, Size: asset.size})
+	}
+
+	if !d.client.draft {
+		fmt.Printf("[dry-run] PATCH %s\n%s\n", d.client.url("releases/<id>"), publishBody)
+	}
+
+	return out, nil
+}
+
+func (d DryRun) reconcile(existing *releaseResponse) versions.Release {
+	fmt.Printf("Release %s exists\n", existing.TagName)
+
+	out := versions.Release{ID: existing.ID, URL: existing.HTMLURL, ChangeLog: existing.Body, Draft: existing.Draft}
+	for _, asset := range d.client.assets {
+		id, uploaded := existing.asset(asset.name)
+		if id != 0 && !uploaded {
+			fmt.Printf("[dry-run] DELETE %s\n", d.client.url(fmt.Sprintf("releases/assets/%d", id)))
+		}
+		if !uploaded {
+			fmt.Printf("[dry-run] upload %s (%d bytes)\n", asset.name, asset.size)
+		}
+		out.Assets = append(out.Assets, versions.ReleaseAsset{Name: asset.name, Size: asset.size})
+	}
+
+	if existing.Draft && !d.client.draft {
+		fmt.Printf("[dry-run] PATCH %s\n%s\n", d.client.url(fmt.Sprintf("releases/%d", existing.ID)), publishBody)
+	}
+
+	return out
 }
 
-func (c *Client) uploadAsset(url string, file Asset) error {
-	url = strings.Replace(url, "{?name,label}", "?name="+file.name, 1)
+func (c *Client) uploadAsset(ctx context.Context, release *releaseResponse, file Asset) error {
+	url := strings.Replace(release.UploadURL, "{?name,label}", "?name="+file.name, 1)
 
 	req := &request{
 		method: http.MethodPost,
@@ -193,16 +885,63 @@ func (c *Client) uploadAsset(url string, file Asset) error {
 		headers: map[string]string{
 			"Content-Type": "application/octet-stream",
 		},
+		created: func(ctx context.Context, _ any) (bool, error) {
+			return c.uploaded(ctx, release.ID, file.name)
+		},
+	}
+
+	return c.send(ctx, req, nil)
+}
+
+type assetResponse struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
+
+// uploaded tells whether the release has the asset, deleting a broken upload so it can be sent again.
+func (c *Client) uploaded(ctx context.Context, id int64, name string) (bool, error) {
+	for url := c.url(fmt.Sprintf("releases/%d/assets?per_page=100", id)); url != ""; {
+		req := &request{
+			method: http.MethodGet,
+			name:   "assets",
+			url:    url,
+		}
+
+		var assets []assetResponse
+		next, err := c.sendPage(ctx, req, &assets)
+		if err != nil {
+			return false, err
+		}
+
+		for _, asset := range assets {
+			if asset.Name != name {
+				continue
+			}
+
+			if asset.State == "uploaded" {
+				return true, nil
+			}
+
+			return false, c.deleteAsset(ctx, asset.ID)
+		}
+
+		url = next
 	}
 
-	return c.send(req, nil)
+	return false, nil
 }
 
 type errorResponse struct {
 	Message string `json:"message"`
 }
 
-func (c *Client) send(in *request, out any) (err error) {
+func (c *Client) send(ctx context.Context, in *request, out any) error {
+	_, err := c.sendPage(ctx, in, out)
+	return err
+}
+
+func (c *Client) sendPage(ctx context.Context, in *request, out any) (next string, err error) {
 	defer func() {
 		if err != nil {
 			fmt.Println("DEBUG info:")
@@ -212,13 +951,70 @@ func (c *Client) send(in *request, out any) (err error) {
 		}
 	}()
 
-	req, err := http.NewRequest(in.method, in.url, in.reader)
+	for attempt := 1; ; attempt++ {
+		next, err = c.do(ctx, in, out)
+
+		var retry *retryError
+		if !errors.As(err, &retry) || attempt > c.retry.Retries || retry.processed && !in.resend() {
+			return next, err
+		}
+
+		wait, ok := c.retry.wait(attempt, retry, c.now())
+		if !ok {
+			return "", fmt.Errorf("%w, retry in %s exceeds %s", err, wait.Round(time.Second), c.retry.maxWait())
+		}
+
+		if err := rewind(in.reader); err != nil {
+			return "", fmt.Errorf("%s: retry: %w", in.name, err)
+		}
+
+		c.debugInfo = append(c.debugInfo, fmt.Sprintf("%s retry %d in %s", in.name, attempt, wait))
+		if err := c.sleep(ctx, wait); err != nil {
+			return "", fmt.Errorf("%s: retry: %w", in.name, err)
+		}
+
+		if retry.processed && in.created != nil {
+			created, cerr := in.created(ctx, out)
+			if cerr != nil {
+				return "", errors.Join(err, fmt.Errorf("%s: retry: %w", in.name, cerr))
+			}
+
+			if created {
+				c.debugInfo = append(c.debugInfo, fmt.Sprintf("%s took effect despite the error", in.name))
+				return "", nil
+			}
+		}
+	}
+}
+
+func (c *Client) do(ctx context.Context, in *request, out any) (string, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	body := in.reader
+	if _, ok := body.(io.Closer); ok {
+		body = io.NopCloser(body) // keep asset files open so they can be rewound between attempts
+	}
+
+	var wrote atomic.Bool
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		WroteRequest: func(info httptrace.WroteRequestInfo) { wrote.Store(info.Err == nil) },
+	})
+
+	req, err := http.NewRequestWithContext(ctx, in.method, in.url, body)
 	if err != nil {
-		return fmt.Errorf("http.NewRequest: %w", err)
+		return "", fmt.Errorf("http.NewRequestWithContext: %w", err)
 	}
 
 	req.Header.Set("Accept", "application/vnd.github+json")
-	req.Header.Set("Authorization", "Bearer "+c.token)
+	token, err := c.authorization(ctx)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", in.name, err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
 	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
 
 	for k, v := range in.headers {
@@ -233,30 +1029,56 @@ func (c *Client) send(in *request, out any) (err error) {
 
 	res, err := c.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("client.Do: %w", err)
+		return "", &retryError{err: fmt.Errorf("client.Do: %w", err), processed: wrote.Load()}
 	}
 	defer res.Body.Close()
 
 	raw, err := io.ReadAll(res.Body)
 	if err != nil {
-		return fmt.Errorf("io.ReadAll: %w", err)
+		return "", &retryError{err: fmt.Errorf("io.ReadAll: %w", err), processed: true}
 	}
 
-	c.debugInfo = append(c.debugInfo, fmt.Sprintf("%s response: %s, %s\n", in.name, res.Status, raw))
+	logged := string(raw)
+	if in.secret {
+		logged = "<redacted>"
+	}
+	c.debugInfo = append(c.debugInfo, fmt.Sprintf("%s response: %s, %s\n", in.name, res.Status, logged))
 
 	if !strings.HasPrefix(res.Status, "2") {
 		var errRes errorResponse
-		if err := json.Unmarshal(raw, &errRes); err != nil && len(raw) != 0 {
-			return fmt.Errorf("error json.Unmarshal: %w", err)
+		if err := json.Unmarshal(raw, &errRes); err != nil || errRes.Message == "" {
+			errRes.Message = res.Status
+		}
+
+		err := fmt.Errorf("%s failed: %s", in.name, errRes.Message)
+		if retryable(res) {
+			return "", &retryError{err: err, header: res.Header, processed: res.StatusCode >= 500}
 		}
-		return fmt.Errorf("%s failed: %s", in.name, errRes.Message)
+		return "", err
 	}
 
 	if out != nil {
 		if err := json.Unmarshal(raw, &out); err != nil {
-			return fmt.Errorf("out json.Unmarshal: %w", err)
+			return "", fmt.Errorf("out json.Unmarshal: %w", err)
 		}
 	}
 
-	return nil
+	return nextPage(res.Header.Get("Link")), nil
+}
+
+func nextPage(link string) string {
+	for _, part := range strings.Split(link, ",") {
+		url, params, ok := strings.Cut(part, ";")
+		if !ok {
+			continue
+		}
+
+		for _, param := range strings.Split(params, ";") {
+			if strings.TrimSpace(param) == `rel="next"` {
+				return strings.Trim(strings.TrimSpace(url), "<>")
+			}
+		}
+	}
+
+	return ""
 }
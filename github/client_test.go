@@ -1,18 +1,29 @@
 package github
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/agukrapo/tagger/changelog"
 	"github.com/agukrapo/tagger/versions"
 )
 
 func TestClient_LatestTag(t *testing.T) {
 	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if strings.Contains(req.URL.Path, "/compare/") {
+			_, _ = w.Write([]byte(`{"status":"behind"}`))
+			return
+		}
 		_, _ = w.Write(readFile(t, "test-data/tag-response.json"))
 	}))
 	defer svr.Close()
@@ -22,7 +33,7 @@ func TestClient_LatestTag(t *testing.T) {
 		host:   svr.URL,
 	}
 
-	got, err := c.LatestTag()
+	got, err := c.LatestTag(t.Context())
 	if err != nil {
 		t.Fatalf("LatestTag() error = %v", err)
 	}
@@ -33,268 +44,103 @@ func TestClient_LatestTag(t *testing.T) {
 	}
 }
 
-type T struct {
-	Url          string `json:"url"`
-	HtmlUrl      string `json:"html_url"`
-	PermalinkUrl string `json:"permalink_url"`
-	DiffUrl      string `json:"diff_url"`
-	PatchUrl     string `json:"patch_url"`
-	BaseCommit   struct {
-		Sha    string `json:"sha"`
-		NodeId string `json:"node_id"`
-		Commit struct {
-			Author struct {
-				Name  string    `json:"name"`
-				Email string    `json:"email"`
-				Date  time.Time `json:"date"`
-			} `json:"author"`
-			Committer struct {
-				Name  string    `json:"name"`
-				Email string    `json:"email"`
-				Date  time.Time `json:"date"`
-			} `json:"committer"`
-			Message string `json:"message"`
-			Tree    struct {
-				Sha string `json:"sha"`
-				Url string `json:"url"`
-			} `json:"tree"`
-			Url          string `json:"url"`
-			CommentCount int    `json:"comment_count"`
-			Verification struct {
-				Verified   bool        `json:"verified"`
-				Reason     string      `json:"reason"`
-				Signature  string      `json:"signature"`
-				Payload    string      `json:"payload"`
-				VerifiedAt interface{} `json:"verified_at"`
-			} `json:"verification"`
-		} `json:"commit"`
-		Url         string `json:"url"`
-		HtmlUrl     string `json:"html_url"`
-		CommentsUrl string `json:"comments_url"`
-		Author      struct {
-			Login             string `json:"login"`
-			Id                int    `json:"id"`
-			NodeId            string `json:"node_id"`
-			AvatarUrl         string `json:"avatar_url"`
-			GravatarId        string `json:"gravatar_id"`
-			Url               string `json:"url"`
-			HtmlUrl           string `json:"html_url"`
-			FollowersUrl      string `json:"followers_url"`
-			FollowingUrl      string `json:"following_url"`
-			GistsUrl          string `json:"gists_url"`
-			StarredUrl        string `json:"starred_url"`
-			SubscriptionsUrl  string `json:"subscriptions_url"`
-			OrganizationsUrl  string `json:"organizations_url"`
-			ReposUrl          string `json:"repos_url"`
-			EventsUrl         string `json:"events_url"`
-			ReceivedEventsUrl string `json:"received_events_url"`
-			Type              string `json:"type"`
-			UserViewType      string `json:"user_view_type"`
-			SiteAdmin         bool   `json:"site_admin"`
-		} `json:"author"`
-		Committer struct {
-			Login             string `json:"login"`
-			Id                int    `json:"id"`
-			NodeId            string `json:"node_id"`
-			AvatarUrl         string `json:"avatar_url"`
-			GravatarId        string `json:"gravatar_id"`
-			Url               string `json:"url"`
-			HtmlUrl           string `json:"html_url"`
-			FollowersUrl      string `json:"followers_url"`
-			FollowingUrl      string `json:"following_url"`
-			GistsUrl          string `json:"gists_url"`
-			StarredUrl        string `json:"starred_url"`
-			SubscriptionsUrl  string `json:"subscriptions_url"`
-			OrganizationsUrl  string `json:"organizations_url"`
-			ReposUrl          string `json:"repos_url"`
-			EventsUrl         string `json:"events_url"`
-			ReceivedEventsUrl string `json:"received_events_url"`
-			Type              string `json:"type"`
-			UserViewType      string `json:"user_view_type"`
-			SiteAdmin         bool   `json:"site_admin"`
-		} `json:"committer"`
-		Parents []struct {
-			Sha     string `json:"sha"`
-			Url     string `json:"url"`
-			HtmlUrl string `json:"html_url"`
-		} `json:"parents"`
-	} `json:"base_commit"`
-	MergeBaseCommit struct {
-		Sha    string `json:"sha"`
-		NodeId string `json:"node_id"`
-		Commit struct {
-			Author struct {
-				Name  string    `json:"name"`
-				Email string    `json:"email"`
-				Date  time.Time `json:"date"`
-			} `json:"author"`
-			Committer struct {
-				Name  string    `json:"name"`
-				Email string    `json:"email"`
-				Date  time.Time `json:"date"`
-			} `json:"committer"`
-			Message string `json:"message"`
-			Tree    struct {
-				Sha string `json:"sha"`
-				Url string `json:"url"`
-			} `json:"tree"`
-			Url          string `json:"url"`
-			CommentCount int    `json:"comment_count"`
-			Verification struct {
-				Verified   bool        `json:"verified"`
-				Reason     string      `json:"reason"`
-				Signature  string      `json:"signature"`
-				Payload    string      `json:"payload"`
-				VerifiedAt interface{} `json:"verified_at"`
-			} `json:"verification"`
-		} `json:"commit"`
-		Url         string `json:"url"`
-		HtmlUrl     string `json:"html_url"`
-		CommentsUrl string `json:"comments_url"`
-		Author      struct {
-			Login             string `json:"login"`
-			Id                int    `json:"id"`
-			NodeId            string `json:"node_id"`
-			AvatarUrl         string `json:"avatar_url"`
-			GravatarId        string `json:"gravatar_id"`
-			Url               string `json:"url"`
-			HtmlUrl           string `json:"html_url"`
-			FollowersUrl      string `json:"followers_url"`
-			FollowingUrl      string `json:"following_url"`
-			GistsUrl          string `json:"gists_url"`
-			StarredUrl        string `json:"starred_url"`
-			SubscriptionsUrl  string `json:"subscriptions_url"`
-			OrganizationsUrl  string `json:"organizations_url"`
-			ReposUrl          string `json:"repos_url"`
-			EventsUrl         string `json:"events_url"`
-			ReceivedEventsUrl string `json:"received_events_url"`
-			Type              string `json:"type"`
-			UserViewType      string `json:"user_view_type"`
-			SiteAdmin         bool   `json:"site_admin"`
-		} `json:"author"`
-		Committer struct {
-			Login             string `json:"login"`
-			Id                int    `json:"id"`
-			NodeId            string `json:"node_id"`
-			AvatarUrl         string `json:"avatar_url"`
-			GravatarId        string `json:"gravatar_id"`
-			Url               string `json:"url"`
-			HtmlUrl           string `json:"html_url"`
-			FollowersUrl      string `json:"followers_url"`
-			FollowingUrl      string `json:"following_url"`
-			GistsUrl          string `json:"gists_url"`
-			StarredUrl        string `json:"starred_url"`
-			SubscriptionsUrl  string `json:"subscriptions_url"`
-			OrganizationsUrl  string `json:"organizations_url"`
-			ReposUrl          string `json:"repos_url"`
-			EventsUrl         string `json:"events_url"`
-			ReceivedEventsUrl string `json:"received_events_url"`
-			Type              string `json:"type"`
-			UserViewType      string `json:"user_view_type"`
-			SiteAdmin         bool   `json:"site_admin"`
-		} `json:"committer"`
-		Parents []struct {
-			Sha     string `json:"sha"`
-			Url     string `json:"url"`
-			HtmlUrl string `json:"html_url"`
-		} `json:"parents"`
-	} `json:"merge_base_commit"`
-	Status       string `json:"status"`
-	AheadBy      int    `json:"ahead_by"`
-	BehindBy     int    `json:"behind_by"`
-	TotalCommits int    `json:"total_commits"`
-	Commits      []struct {
-		Sha    string `json:"sha"`
-		NodeId string `json:"node_id"`
-		Commit struct {
-			Author struct {
-				Name  string    `json:"name"`
-				Email string    `json:"email"`
-				Date  time.Time `json:"date"`
-			} `json:"author"`
-			Committer struct {
-				Name  string    `json:"name"`
-				Email string    `json:"email"`
-				Date  time.Time `json:"date"`
-			} `json:"committer"`
-			Message string `json:"message"`
-			Tree    struct {
-				Sha string `json:"sha"`
-				Url string `json:"url"`
-			} `json:"tree"`
-			Url          string `json:"url"`
-			CommentCount int    `json:"comment_count"`
-			Verification struct {
-				Verified   bool       `json:"verified"`
-				Reason     string     `json:"reason"`
-				Signature  *string    `json:"signature"`
-				Payload    *string    `json:"payload"`
-				VerifiedAt *time.Time `json:"verified_at"`
-			} `json:"verification"`
-		} `json:"commit"`
-		Url         string `json:"url"`
-		HtmlUrl     string `json:"html_url"`
-		CommentsUrl string `json:"comments_url"`
-		Author      struct {
-			Login             string `json:"login"`
-			Id                int    `json:"id"`
-			NodeId            string `json:"node_id"`
-			AvatarUrl         string `json:"avatar_url"`
-			GravatarId        string `json:"gravatar_id"`
-			Url               string `json:"url"`
-			HtmlUrl           string `json:"html_url"`
-			FollowersUrl      string `json:"followers_url"`
-			FollowingUrl      string `json:"following_url"`
-			GistsUrl          string `json:"gists_url"`
-			StarredUrl        string `json:"starred_url"`
-			SubscriptionsUrl  string `json:"subscriptions_url"`
-			OrganizationsUrl  string `json:"organizations_url"`
-			ReposUrl          string `json:"repos_url"`
-			EventsUrl         string `json:"events_url"`
-			ReceivedEventsUrl string `json:"received_events_url"`
-			Type              string `json:"type"`
-			UserViewType      string `json:"user_view_type"`
-			SiteAdmin         bool   `json:"site_admin"`
-		} `json:"author"`
-		Committer struct {
-			Login             string `json:"login"`
-			Id                int    `json:"id"`
-			NodeId            string `json:"node_id"`
-			AvatarUrl         string `json:"avatar_url"`
-			GravatarId        string `json:"gravatar_id"`
-			Url               string `json:"url"`
-			HtmlUrl           string `json:"html_url"`
-			FollowersUrl      string `json:"followers_url"`
-			FollowingUrl      string `json:"following_url"`
-			GistsUrl          string `json:"gists_url"`
-			StarredUrl        string `json:"starred_url"`
-			SubscriptionsUrl  string `json:"subscriptions_url"`
-			OrganizationsUrl  string `json:"organizations_url"`
-			ReposUrl          string `json:"repos_url"`
-			EventsUrl         string `json:"events_url"`
-			ReceivedEventsUrl string `json:"received_events_url"`
-			Type              string `json:"type"`
-			UserViewType      string `json:"user_view_type"`
-			SiteAdmin         bool   `json:"site_admin"`
-		} `json:"committer"`
-		Parents []struct {
-			Sha     string `json:"sha"`
-			Url     string `json:"url"`
-			HtmlUrl string `json:"html_url"`
-		} `json:"parents"`
-	} `json:"commits"`
-	Files []struct {
-		Sha         string `json:"sha"`
-		Filename    string `json:"filename"`
-		Status      string `json:"status"`
-		Additions   int    `json:"additions"`
-		Deletions   int    `json:"deletions"`
-		Changes     int    `json:"changes"`
-		BlobUrl     string `json:"blob_url"`
-		RawUrl      string `json:"raw_url"`
-		ContentsUrl string `json:"contents_url"`
-		Patch       string `json:"patch,omitempty"`
-	} `json:"files"`
+func TestClient_LatestTag_precedence(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if strings.Contains(req.URL.Path, "/compare/") {
+			_, _ = w.Write([]byte(`{"status":"behind"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`[{"name":"v1.2.1"},{"name":"v2.0.0-rc.1"},{"name":"v1.10.0"},{"name":"latest"}]`))
+	}))
+	defer svr.Close()
+
+	c := Client{
+		client: svr.Client(),
+		host:   svr.URL,
+	}
+
+	got, err := c.LatestTag(t.Context())
+	if err != nil {
+		t.Fatalf("LatestTag() error = %v", err)
+	}
+
+	want := versions.Tag("v2.0.0-rc.1")
+	if got != want {
+		t.Errorf("LatestTag() got = %v, want %v", got, want)
+	}
+}
+
+func TestClient_LatestTag_pagination(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if strings.Contains(req.URL.Path, "/compare/") {
+			_, _ = w.Write([]byte(`{"status":"behind"}`))
+			return
+		}
+
+		switch req.URL.Query().Get("page") {
+		case "":
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s/tags?page=2>; rel="next", <http://%s/tags?page=3>; rel="last"`, req.Host, req.Host))
+			_, _ = w.Write([]byte(`[{"name":"v1.9.0"},{"name":"v1.8.0"}]`))
+		case "2":
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s/tags?page=3>; rel="next"`, req.Host))
+			_, _ = w.Write([]byte(`[{"name":"v1.10.0"}]`))
+		case "3":
+			_, _ = w.Write([]byte(`[{"name":"v1.7.0"}]`))
+		}
+	}))
+	defer svr.Close()
+
+	c := Client{
+		client: svr.Client(),
+		host:   svr.URL,
+	}
+
+	got, err := c.LatestTag(t.Context())
+	if err != nil {
+		t.Fatalf("LatestTag() error = %v", err)
+	}
+
+	want := versions.Tag("v1.10.0")
+	if got != want {
+		t.Errorf("LatestTag() got = %v, want %v", got, want)
+	}
+}
+
+func TestClient_Tags_unreachable(t *testing.T) {
+	var compares []string
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if strings.HasPrefix(req.URL.Path, "/repos/o/r/compare/") {
+			compares = append(compares, req.URL.Path)
+		}
+
+		switch req.URL.Path {
+		case "/repos/o/r/tags":
+			_, _ = w.Write([]byte(`[{"name":"v2.0.0-rc.1","commit":{"sha":"rc"}},{"name":"v1.5","commit":{"sha":"old"}},{"name":"v1.4","commit":{"sha":"main"}},{"name":"v1.4.0","commit":{"sha":"main"}},{"name":"v1.3","commit":{"sha":"older"}},{"name":"latest","commit":{"sha":"main"}}]`))
+		case "/repos/o/r/compare/HEAD...v2.0.0-rc.1":
+			_, _ = w.Write([]byte(`{"status":"diverged"}`))
+		case "/repos/o/r/compare/HEAD...v1.5":
+			_, _ = w.Write([]byte(`{"status":"ahead","ahead_by":3}`))
+		case "/repos/o/r/compare/HEAD...v1.4":
+			_, _ = w.Write([]byte(`{"status":"behind"}`))
+		default:
+			t.Errorf("unexpected request %s", req.URL)
+		}
+	}))
+	defer svr.Close()
+
+	got, err := New("o", "r", svr.URL, "", Options{}).Tags(t.Context())
+	if err != nil {
+		t.Fatalf("Tags() err = %v", err)
+	}
+
+	if want := []versions.Tag{"v1.4"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Tags() got = %v, want %v", got, want)
+	}
+
+	// v1.4.0 shares the v1.4 commit and v1.3 is older than the latest merged tag
+	if want := []string{"/repos/o/r/compare/HEAD...v2.0.0-rc.1", "/repos/o/r/compare/HEAD...v1.5", "/repos/o/r/compare/HEAD...v1.4"}; !reflect.DeepEqual(compares, want) {
+		t.Errorf("Tags() compares = %q, want %q", compares, want)
+	}
 }
 
 func TestClient_CommitsSince(t *testing.T) {
@@ -308,7 +154,7 @@ func TestClient_CommitsSince(t *testing.T) {
 		host:   svr.URL,
 	}
 
-	got, err := c.CommitsSince("v4.1.1")
+	got, err := c.CommitsSince(t.Context(), "v4.1.1")
 	if err != nil {
 		t.Fatalf("CommitsSince() error = %v", err)
 	}
@@ -318,6 +164,571 @@ func TestClient_CommitsSince(t *testing.T) {
 	}
 }
 
+func TestClient_CommitsSince_pagination(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("page") == "" {
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s/compare?page=2>; rel="next"`, req.Host))
+			_, _ = w.Write(readFile(t, "test-data/compare-response.json"))
+			return
+		}
+		_, _ = w.Write([]byte(`{"commits":[{"sha":"abc123","commit":{"message":"fix: last page"}}]}`))
+	}))
+	defer svr.Close()
+
+	c := Client{
+		client: svr.Client(),
+		host:   svr.URL,
+	}
+
+	got, err := c.CommitsSince(t.Context(), "v4.1.1")
+	if err != nil {
+		t.Fatalf("CommitsSince() error = %v", err)
+	}
+
+	if len(got) != 16 {
+		t.Fatalf("CommitsSince() len(got) = %v, want 16", len(got))
+	}
+
+	if got[15].SHA() != "abc123" {
+		t.Errorf("CommitsSince() got[15].SHA() = %v, want abc123", got[15].SHA())
+	}
+}
+
+func TestClient_CommitsSince_pullRequests(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/repos/o/r/compare/v1...HEAD":
+			_, _ = w.Write([]byte(`{"commits":[
+				{"sha":"a1","commit":{"message":"feat: add Polish language"},"author":{"login":"alice"}},
+				{"sha":"b2","commit":{"message":"fix: typo"},"author":null},
+				{"sha":"c3","commit":{"message":"fix: other"},"author":{"login":"carol"}}
+			]}`))
+		case "/repos/o/r/commits/a1/pulls":
+			_, _ = w.Write([]byte(`[{"number":122,"title":"closed"},{"number":123,"title":"Polish","merged_at":"2025-01-01T00:00:00Z","user":{"login":"alice"}}]`))
+		case "/repos/o/r/commits/b2/pulls":
+			_, _ = w.Write([]byte(`[{"number":124,"title":"Typo","merged_at":"2025-01-01T00:00:00Z","user":{"login":"bob"}}]`))
+		case "/repos/o/r/commits/c3/pulls":
+			_, _ = w.Write([]byte(`[]`))
+		case "/repos/o/r/commits":
+			if req.URL.Query().Get("sha") != "v1" {
+				t.Errorf("unexpected query %s", req.URL.RawQuery)
+			}
+			if req.URL.Query().Get("author") == "carol" {
+				_, _ = w.Write([]byte(`[{"sha":"z9"}]`))
+				return
+			}
+			_, _ = w.Write([]byte(`[]`))
+		default:
+			t.Errorf("unexpected request %s", req.URL)
+		}
+	}))
+	defer svr.Close()
+
+	c := New("o", "r", svr.URL, "", Options{PullRequests: true, Contributors: true})
+
+	got, err := c.CommitsSince(t.Context(), "v1")
+	if err != nil {
+		t.Fatalf("CommitsSince() error = %v", err)
+	}
+
+	want := []struct {
+		author string
+		pr     versions.PullRequest
+		first  bool
+	}{
+		{"alice", versions.PullRequest{Number: 123, Title: "Polish"}, true},
+		{"bob", versions.PullRequest{Number: 124, Title: "Typo"}, true},
+		{"carol", versions.PullRequest{}, false},
+	}
+
+	for i, w := range want {
+		if got[i].Author() != w.author || got[i].PullRequest() != w.pr || got[i].FirstContribution() != w.first {
+			t.Errorf("CommitsSince()[%d] got = %s %v %t, want %v", i, got[i].Author(), got[i].PullRequest(), got[i].FirstContribution(), w)
+		}
+	}
+}
+
+func TestClient_PullRequestCommits(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/repos/o/r/pulls/7/commits" {
+			t.Errorf("unexpected path %s", req.URL.Path)
+		}
+		_, _ = w.Write([]byte(`[
+			{"sha":"a1","commit":{"message":"feat: x\n\nbody"},"parents":[{"sha":"p"}]},
+			{"sha":"b2","commit":{"message":"Merge branch 'main'"},"parents":[{"sha":"p"},{"sha":"q"}]},
+			{"sha":"c3","commit":{"message":"wip"},"parents":[{"sha":"a1"}]}
+		]`))
+	}))
+	defer svr.Close()
+
+	c := Client{
+		client: svr.Client(),
+		owner:  "o",
+		repo:   "r",
+		host:   svr.URL,
+	}
+
+	got, err := c.PullRequestCommits(t.Context(), 7)
+	if err != nil {
+		t.Fatalf("PullRequestCommits() error = %v", err)
+	}
+
+	if len(got) != 2 || got[0].SHA() != "a1" || got[1].SHA() != "c3" {
+		t.Errorf("PullRequestCommits() got = %v", got)
+	}
+}
+
+func TestDryRun_Release(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		t.Errorf("unexpected request %s %s", req.Method, req.URL)
+	}))
+	defer svr.Close()
+
+	c := New("o", "r", svr.URL, "", Options{
+		Assets: []Asset{NewAsset("bin", strings.NewReader("data"), 4)},
+	})
+
+	commits := []*versions.Commit{versions.NewCommit("a1", "feat: new")}
+	got, err := c.DryRun().Release(t.Context(), versions.Changes{Commits: commits})
+	if err != nil {
+		t.Fatalf("Release() err = %v", err)
+	}
+
+	want := "#### New features:\n- [new](" + svr.URL + "/o/r/commit/a1)\n"
+	if got.ChangeLog != want {
+		t.Errorf("Release() ChangeLog = %q, want %q", got.ChangeLog, want)
+	}
+}
+
+func TestClient_Release_notes(t *testing.T) {
+	tests := []struct {
+		notes Notes
+		want  string
+	}{
+		{ConventionalNotes, "#### Bug fixes:\n- [b](https://github.com/o/r/commit/a1)\n"},
+		{GeneratedNotes, "## What's Changed\n* b by @alice\n"},
+		{MergedNotes, "#### Bug fixes:\n- [b](https://github.com/o/r/commit/a1)\n\n## What's Changed\n* b by @alice\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.notes.String(), func(t *testing.T) {
+			var generated bool
+			svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				raw, _ := io.ReadAll(req.Body)
+
+				switch req.URL.Path {
+				case "/repos/o/r/releases/generate-notes":
+					generated = true
+					if want := `{"tag_name":"v0","previous_tag_name":"v1"}`; string(raw) != want {
+						t.Errorf("generate-notes body = %s, want %s", raw, want)
+					}
+					_, _ = w.Write([]byte(`{"name":"v0","body":"## What's Changed\n* b by @alice\n"}`))
+				case "/repos/o/r/releases":
+					var body struct {
+						Body string `json:"body"`
+					}
+					if err := json.Unmarshal(raw, &body); err != nil || body.Body != tt.want {
+						t.Errorf("release body = %q %v, want %q", body.Body, err, tt.want)
+					}
+					_, _ = w.Write([]byte(`{"id":1}`))
+				case "/repos/o/r/releases/1":
+					_, _ = w.Write([]byte(`{"id":1}`))
+				default:
+					t.Errorf("unexpected request %s", req.URL)
+				}
+			}))
+			defer svr.Close()
+
+			c := New("o", "r", svr.URL, "", Options{
+				ChangeLog: changelog.New(changelog.Options{URLs: NewLinks("", "o", "r")}),
+				Notes:     tt.notes,
+			})
+
+			got, err := c.Release(t.Context(), versions.Changes{
+				Tag:     "v1",
+				Commits: []*versions.Commit{versions.NewCommit("a1", "fix: b")},
+			})
+			if err != nil {
+				t.Fatalf("Release() err = %v", err)
+			}
+
+			if got.ChangeLog != tt.want {
+				t.Errorf("Release() ChangeLog = %q, want %q", got.ChangeLog, tt.want)
+			}
+
+			if generated != (tt.notes != ConventionalNotes) {
+				t.Errorf("generate-notes called = %t", generated)
+			}
+		})
+	}
+}
+
+func TestClient_Release_draft(t *testing.T) {
+	tests := []struct {
+		name      string
+		draft     bool
+		upload    int
+		want      []string
+		wantURL   string
+		wantErr   string
+		wantDraft bool
+	}{
+		{
+			name:    "publish after upload",
+			upload:  http.StatusCreated,
+			want:    []string{"POST /repos/o/r/releases", "POST /upload", "PATCH /repos/o/r/releases/1 {\"draft\":false}"},
+			wantURL: "https://github.com/o/r/releases/tag/v0",
+		},
+		{
+			name:      "keep draft",
+			draft:     true,
+			upload:    http.StatusCreated,
+			want:      []string{"POST /repos/o/r/releases", "POST /upload"},
+			wantURL:   "https://github.com/o/r/releases/tag/untagged-1",
+			wantDraft: true,
+		},
+		{
+			name:    "upload failure",
+			upload:  http.StatusBadGateway,
+			want:    []string{"POST /repos/o/r/releases", "POST /upload", "DELETE /repos/o/r/releases/1"},
+			wantErr: "upload failed: bad gateway, draft release 1 deleted",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				raw, _ := io.ReadAll(req.Body)
+
+				switch req.Method + " " + req.URL.Path {
+				case "POST /repos/o/r/releases":
+					if !strings.Contains(string(raw), `"draft":true`) {
+						t.Errorf("release body = %s, want a draft", raw)
+					}
+					got = append(got, "POST /repos/o/r/releases")
+					_, _ = fmt.Fprintf(w, `{"id":1,"html_url":"https://github.com/o/r/releases/tag/untagged-1","upload_url":"http://%s/upload{?name,label}"}`, req.Host)
+				case "POST /upload":
+					got = append(got, "POST /upload")
+					w.WriteHeader(tt.upload)
+					if tt.upload != http.StatusCreated {
+						_, _ = w.Write([]byte(`{"message":"bad gateway"}`))
+					}
+				case "PATCH /repos/o/r/releases/1":
+					got = append(got, "PATCH /repos/o/r/releases/1 "+string(raw))
+					_, _ = w.Write([]byte(`{"id":1,"html_url":"https://github.com/o/r/releases/tag/v0"}`))
+				case "DELETE /repos/o/r/releases/1":
+					got = append(got, "DELETE /repos/o/r/releases/1")
+					w.WriteHeader(http.StatusNoContent)
+				default:
+					t.Errorf("unexpected request %s %s", req.Method, req.URL)
+				}
+			}))
+			defer svr.Close()
+
+			c := New("o", "r", svr.URL, "", Options{
+				Assets: []Asset{NewAsset("bin", strings.NewReader("data"), 4)},
+				Draft:  tt.draft,
+			})
+
+			res, err := c.Release(t.Context(), versions.Changes{Commits: []*versions.Commit{versions.NewCommit("a1", "fix: b")}})
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("Release() err = %v, want %s", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("Release() err = %v", err)
+			}
+
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Release() requests = %q, want %q", got, tt.want)
+			}
+
+			if res.URL != tt.wantURL || res.Draft != tt.wantDraft {
+				t.Errorf("Release() got = %+v", res)
+			}
+		})
+	}
+}
+
+func TestClient_HeadTags(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/repos/o/r/tags":
+			_, _ = w.Write([]byte(`[{"name":"v1.1","commit":{"sha":"abc"}},{"name":"v1.1.0","commit":{"sha":"abc"}},{"name":"v1","commit":{"sha":"def"}},{"name":"latest","commit":{"sha":"abc"}}]`))
+		case "/repos/o/r/compare/HEAD...v1.1":
+			_, _ = w.Write([]byte(`{"status":"identical"}`))
+		case "/repos/o/r/compare/HEAD...v1":
+			_, _ = w.Write([]byte(`{"status":"behind"}`))
+		default:
+			t.Errorf("unexpected request %s", req.URL)
+		}
+	}))
+	defer svr.Close()
+
+	got, err := New("o", "r", svr.URL, "", Options{}).HeadTags(t.Context())
+	if err != nil {
+		t.Fatalf("HeadTags() err = %v", err)
+	}
+
+	if want := []versions.Tag{"v1.1", "v1.1.0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("HeadTags() got = %v, want %v", got, want)
+	}
+}
+
+func TestClient_HeadTags_release(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/repos/o/r/tags":
+			_, _ = w.Write([]byte(`[{"name":"v1.3","commit":{"sha":"next"}},{"name":"v1.2","commit":{"sha":"release"}},{"name":"v1.1","commit":{"sha":"old"}}]`))
+		case "/repos/o/r/compare/HEAD...v1.3":
+			_, _ = w.Write([]byte(`{"status":"ahead","ahead_by":2,"commits":[{"sha":"release","commit":{"message":"chore: release v1.2\n\nTagger-Release: v1.2"}}]}`))
+		case "/repos/o/r/compare/HEAD...v1.2":
+			_, _ = w.Write([]byte(`{"status":"ahead","ahead_by":1,"commits":[{"sha":"release","commit":{"message":"chore: release v1.2\n\nTagger-Release: v1.2"}}]}`))
+		case "/repos/o/r/compare/HEAD...v1.1":
+			_, _ = w.Write([]byte(`{"status":"behind"}`))
+		default:
+			t.Errorf("unexpected request %s", req.URL)
+		}
+	}))
+	defer svr.Close()
+
+	c := New("o", "r", svr.URL, "", Options{})
+
+	head, err := c.HeadTags(t.Context())
+	if err != nil {
+		t.Fatalf("HeadTags() err = %v", err)
+	}
+
+	if want := []versions.Tag{"v1.2"}; !reflect.DeepEqual(head, want) {
+		t.Errorf("HeadTags() got = %v, want %v", head, want)
+	}
+
+	tags, err := c.Tags(t.Context())
+	if err != nil {
+		t.Fatalf("Tags() err = %v", err)
+	}
+
+	if want := []versions.Tag{"v1.2", "v1.1"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("Tags() got = %v, want %v", tags, want)
+	}
+}
+
+func TestClient_Release_reconcile(t *testing.T) {
+	tests := []struct {
+		name      string
+		releases  string
+		want      []string
+		wantURL   string
+		wantDraft bool
+	}{
+		{
+			name:     "missing release",
+			releases: `[{"id":2,"tag_name":"v1"}]`,
+			want:     []string{"GET /repos/o/r/releases", "POST /repos/o/r/releases", "POST /upload?name=a", "POST /upload?name=b", "PATCH /repos/o/r/releases/1"},
+			wantURL:  "https://github.com/o/r/releases/tag/v0",
+		},
+		{
+			name:     "missing assets",
+			releases: `[{"id":1,"tag_name":"v0","html_url":"https://github.com/o/r/releases/tag/v0","upload_url":"http://{host}/upload{?name,label}","body":"notes","assets":[{"id":7,"name":"a","state":"uploaded"}]}]`,
+			want:     []string{"GET /repos/o/r/releases", "POST /upload?name=b"},
+			wantURL:  "https://github.com/o/r/releases/tag/v0",
+		},
+		{
+			name:     "complete release",
+			releases: `[{"id":1,"tag_name":"v0","html_url":"https://github.com/o/r/releases/tag/v0","body":"notes","assets":[{"id":7,"name":"a","state":"uploaded"},{"id":8,"name":"b","state":"uploaded"}]}]`,
+			want:     []string{"GET /repos/o/r/releases"},
+			wantURL:  "https://github.com/o/r/releases/tag/v0",
+		},
+		{
+			name:     "draft with a broken asset",
+			releases: `[{"id":1,"tag_name":"v0","html_url":"https://github.com/o/r/releases/tag/untagged-1","upload_url":"http://{host}/upload{?name,label}","draft":true,"assets":[{"id":7,"name":"a","state":"uploaded"},{"id":8,"name":"b","state":"starter"}]}]`,
+			want:     []string{"GET /repos/o/r/releases", "DELETE /repos/o/r/releases/assets/8", "POST /upload?name=b", "PATCH /repos/o/r/releases/1"},
+			wantURL:  "https://github.com/o/r/releases/tag/v0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				got = append(got, req.Method+" "+req.URL.Path)
+
+				switch req.Method + " " + req.URL.Path {
+				case "GET /repos/o/r/releases":
+					_, _ = w.Write([]byte(strings.ReplaceAll(tt.releases, "{host}", req.Host)))
+				case "POST /repos/o/r/releases":
+					_, _ = fmt.Fprintf(w, `{"id":1,"upload_url":"http://%s/upload{?name,label}"}`, req.Host)
+				case "POST /upload":
+					got[len(got)-1] += "?" + req.URL.RawQuery
+					w.WriteHeader(http.StatusCreated)
+				case "PATCH /repos/o/r/releases/1":
+					_, _ = w.Write([]byte(`{"id":1,"html_url":"https://github.com/o/r/releases/tag/v0"}`))
+				case "DELETE /repos/o/r/releases/assets/8":
+					w.WriteHeader(http.StatusNoContent)
+				default:
+					t.Errorf("unexpected request %s %s", req.Method, req.URL)
+				}
+			}))
+			defer svr.Close()
+
+			c := New("o", "r", svr.URL, "", Options{
+				Assets: []Asset{NewAsset("a", strings.NewReader("a"), 1), NewAsset("b", strings.NewReader("b"), 1)},
+			})
+
+			res, err := c.Release(t.Context(), versions.Changes{Tagged: true})
+			if err != nil {
+				t.Fatalf("Release() err = %v", err)
+			}
+
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Release() requests = %q, want %q", got, tt.want)
+			}
+
+			if res.URL != tt.wantURL || res.Draft != tt.wantDraft || len(res.Assets) != 2 {
+				t.Errorf("Release() got = %+v", res)
+			}
+		})
+	}
+}
+
+func TestClient_Release_createdDespiteError(t *testing.T) {
+	var got []string
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		got = append(got, req.Method+" "+req.URL.Path)
+
+		switch req.Method + " " + req.URL.Path {
+		case "POST /repos/o/r/releases", "POST /upload":
+			w.WriteHeader(http.StatusBadGateway)
+		case "GET /repos/o/r/releases":
+			_, _ = fmt.Fprintf(w, `[{"id":1,"tag_name":"v0","upload_url":"http://%s/upload{?name,label}","draft":true}]`, req.Host)
+		case "GET /repos/o/r/releases/1/assets":
+			_, _ = w.Write([]byte(`[{"id":7,"name":"a","state":"uploaded"}]`))
+		case "PATCH /repos/o/r/releases/1":
+			_, _ = w.Write([]byte(`{"id":1,"html_url":"https://github.com/o/r/releases/tag/v0"}`))
+		default:
+			t.Errorf("unexpected request %s %s", req.Method, req.URL)
+		}
+	}))
+	defer svr.Close()
+
+	c := New("o", "r", svr.URL, "", Options{
+		Assets: []Asset{NewAsset("a", strings.NewReader("a"), 1)},
+		Retry:  RetryOptions{Retries: 3},
+	})
+	c.sleep = func(context.Context, time.Duration) error { return nil }
+
+	res, err := c.Release(t.Context(), versions.Changes{})
+	if err != nil {
+		t.Fatalf("Release() err = %v", err)
+	}
+
+	want := []string{"POST /repos/o/r/releases", "GET /repos/o/r/releases", "POST /upload", "GET /repos/o/r/releases/1/assets", "PATCH /repos/o/r/releases/1"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Release() requests = %q, want %q", got, want)
+	}
+
+	if res.ID != 1 || res.URL != "https://github.com/o/r/releases/tag/v0" {
+		t.Errorf("Release() got = %+v", res)
+	}
+}
+
+func TestClient_DeleteDraft(t *testing.T) {
+	tests := []struct {
+		name     string
+		releases string
+		want     []string
+	}{
+		{
+			name:     "draft",
+			releases: `[{"id":1,"tag_name":"v0","draft":true}]`,
+			want:     []string{"GET /repos/o/r/releases", "DELETE /repos/o/r/releases/1"},
+		},
+		{
+			name:     "published",
+			releases: `[{"id":1,"tag_name":"v0"}]`,
+			want:     []string{"GET /repos/o/r/releases"},
+		},
+		{
+			name:     "missing",
+			releases: `[]`,
+			want:     []string{"GET /repos/o/r/releases"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				got = append(got, req.Method+" "+req.URL.Path)
+				if req.Method == http.MethodDelete {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				_, _ = w.Write([]byte(tt.releases))
+			}))
+			defer svr.Close()
+
+			if err := New("o", "r", svr.URL, "", Options{}).DeleteDraft(t.Context(), versions.Changes{}); err != nil {
+				t.Fatalf("DeleteDraft() err = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DeleteDraft() requests = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseNotes(t *testing.T) {
+	for in, want := range map[string]Notes{"": ConventionalNotes, "GitHub": GeneratedNotes, "merge": MergedNotes} {
+		if got, err := ParseNotes(in); err != nil || got != want {
+			t.Errorf("ParseNotes(%q) = %v %v, want %v", in, got, err, want)
+		}
+	}
+
+	if _, err := ParseNotes("both"); err == nil {
+		t.Error("ParseNotes(both) err = nil")
+	}
+}
+
+func TestNewLinks(t *testing.T) {
+	tests := []struct {
+		host string
+		want string
+	}{
+		{"", "https://github.com/o/r/commit/a1"},
+		{"https://api.github.com", "https://github.com/o/r/commit/a1"},
+		{"https://ghe.example.com/api/v3", "https://ghe.example.com/o/r/commit/a1"},
+		{"https://api.example.ghe.com/", "https://example.ghe.com/o/r/commit/a1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			if got := NewLinks(tt.host, "o", "r").Commit("a1"); got != tt.want {
+				t.Errorf("Commit() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := NewLinks("", "o", "r").Compare("v1", "v2"); got != "https://github.com/o/r/compare/v1...v2" {
+		t.Errorf("Compare() = %v", got)
+	}
+}
+
+func Test_nextPage(t *testing.T) {
+	tests := []struct {
+		link string
+		want string
+	}{
+		{"", ""},
+		{`<https://api.github.com/tags?page=3>; rel="last"`, ""},
+		{`<https://api.github.com/tags?page=1>; rel="prev", <https://api.github.com/tags?page=3>; rel="next"`, "https://api.github.com/tags?page=3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.link, func(t *testing.T) {
+			if got := nextPage(tt.link); got != tt.want {
+				t.Errorf("nextPage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func readFile(t *testing.T, path string) []byte {
 	t.Helper()
 
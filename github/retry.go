@@ -0,0 +1,112 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultBackoff = time.Second
+	defaultMaxWait = time.Minute
+)
+
+type RetryOptions struct {
+	Retries int
+	Backoff time.Duration
+	MaxWait time.Duration
+}
+
+func (o RetryOptions) backoff() time.Duration {
+	if o.Backoff > 0 {
+		return o.Backoff
+	}
+
+	return defaultBackoff
+}
+
+func (o RetryOptions) maxWait() time.Duration {
+	if o.MaxWait > 0 {
+		return o.MaxWait
+	}
+
+	return defaultMaxWait
+}
+
+// wait honours the rate limit headers and falls back to exponential backoff with jitter.
+func (o RetryOptions) wait(attempt int, err *retryError, now time.Time) (time.Duration, bool) {
+	var out time.Duration
+
+	if after := err.header.Get("Retry-After"); after != "" {
+		if seconds, err := strconv.Atoi(after); err == nil {
+			out = time.Duration(seconds) * time.Second
+		}
+	} else if err.header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(err.header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			out = time.Unix(reset, 0).Sub(now) + time.Second
+		}
+	}
+
+	if out <= 0 {
+		out = min(o.backoff()<<(attempt-1), o.maxWait())
+		out = out/2 + rand.N(out/2+1) // #nosec G404
+	}
+
+	return out, out <= o.maxWait()
+}
+
+type retryError struct {
+	err    error
+	header http.Header
+	// processed tells whether the server may have acted on the request before failing.
+	processed bool
+}
+
+func (e *retryError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryError) Unwrap() error {
+	return e.err
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func retryable(res *http.Response) bool {
+	switch {
+	case res.StatusCode >= 500, res.StatusCode == http.StatusTooManyRequests:
+		return true
+	case res.StatusCode == http.StatusForbidden:
+		return res.Header.Get("Retry-After") != "" || res.Header.Get("X-RateLimit-Remaining") == "0"
+	}
+
+	return false
+}
+
+func rewind(r io.Reader) error {
+	if r == nil {
+		return nil
+	}
+
+	seeker, ok := r.(io.Seeker)
+	if !ok {
+		return errors.New("request body can't be rewound")
+	}
+
+	_, err := seeker.Seek(0, io.SeekStart)
+	return err
+}
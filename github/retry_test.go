@@ -0,0 +1,378 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func retryClient(host string, retries int) (*Client, *[]time.Duration) {
+	var waits []time.Duration
+
+	c := New("o", "r", host, "", Options{Retry: RetryOptions{Retries: retries}})
+	c.sleep = func(_ context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+	c.now = func() time.Time { return time.Unix(1000, 0) }
+
+	return c, &waits
+}
+
+func TestClient_send_retry(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []func(http.ResponseWriter)
+		retries   int
+		error     string
+		waits     []time.Duration
+	}{
+		{
+			name: "server error",
+			responses: []func(http.ResponseWriter){
+				status(http.StatusBadGateway, nil),
+				status(http.StatusOK, nil),
+			},
+			retries: 3,
+		},
+		{
+			name: "retry after",
+			responses: []func(http.ResponseWriter){
+				status(http.StatusTooManyRequests, map[string]string{"Retry-After": "7"}),
+				status(http.StatusOK, nil),
+			},
+			retries: 3,
+			waits:   []time.Duration{7 * time.Second},
+		},
+		{
+			name: "rate limit reset",
+			responses: []func(http.ResponseWriter){
+				status(http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "1010"}),
+				status(http.StatusOK, nil),
+			},
+			retries: 3,
+			waits:   []time.Duration{11 * time.Second},
+		},
+		{
+			name: "rate limit reset too far",
+			responses: []func(http.ResponseWriter){
+				status(http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "5000"}),
+			},
+			retries: 3,
+			error:   "head failed: 403 Forbidden, retry in 1h6m41s exceeds 1m0s",
+		},
+		{
+			name: "forbidden",
+			responses: []func(http.ResponseWriter){
+				status(http.StatusForbidden, nil),
+			},
+			retries: 3,
+			error:   "head failed: 403 Forbidden",
+		},
+		{
+			name: "retries exhausted",
+			responses: []func(http.ResponseWriter){
+				status(http.StatusServiceUnavailable, nil),
+				status(http.StatusServiceUnavailable, nil),
+			},
+			retries: 1,
+			error:   "head failed: 503 Service Unavailable",
+		},
+		{
+			name: "no retries",
+			responses: []func(http.ResponseWriter){
+				status(http.StatusBadGateway, nil),
+			},
+			error: "head failed: 502 Bad Gateway",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				if calls >= len(tt.responses) {
+					t.Errorf("unexpected request %d", calls+1)
+					w.WriteHeader(http.StatusTeapot)
+					return
+				}
+				tt.responses[calls](w)
+				calls++
+			}))
+			defer svr.Close()
+
+			c, waits := retryClient(svr.URL, tt.retries)
+
+			_, err := c.Head(t.Context())
+			if tt.error != "" {
+				if err == nil || err.Error() != tt.error {
+					t.Fatalf("Head() err = %v, want %s", err, tt.error)
+				}
+			} else if err != nil {
+				t.Fatalf("Head() err = %v", err)
+			}
+
+			if calls != len(tt.responses) {
+				t.Errorf("Head() requests = %d, want %d", calls, len(tt.responses))
+			}
+
+			if tt.waits != nil {
+				if len(*waits) != len(tt.waits) || (*waits)[0] != tt.waits[0] {
+					t.Errorf("Head() waits = %v, want %v", *waits, tt.waits)
+				}
+			}
+		})
+	}
+}
+
+func TestClient_send_retryNetworkError(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	svr.Close()
+
+	c, waits := retryClient(svr.URL, 2)
+
+	if _, err := c.Head(t.Context()); err == nil {
+		t.Fatal("Head() err = nil")
+	}
+
+	if len(*waits) != 2 {
+		t.Errorf("Head() waits = %v, want 2", *waits)
+	}
+	for i, wait := range *waits {
+		if limit := time.Second << i; wait < limit/2 || wait > limit {
+			t.Errorf("Head() wait %d = %s, want between %s and %s", i, wait, limit/2, limit)
+		}
+	}
+}
+
+func TestClient_send_retryUpload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "asset.txt")
+	if err := os.WriteFile(path, []byte("content"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var bodies []string
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		raw, _ := io.ReadAll(req.Body)
+		bodies = append(bodies, req.Header.Get("Content-Length")+" "+string(raw))
+
+		if len(bodies) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer svr.Close()
+
+	c, _ := retryClient(svr.URL, 1)
+
+	req := &request{
+		method: http.MethodPost,
+		name:   "upload asset",
+		reader: file,
+		size:   7,
+		url:    svr.URL,
+		created: func(context.Context, any) (bool, error) {
+			return false, nil
+		},
+	}
+	if err := c.send(t.Context(), req, nil); err != nil {
+		t.Fatalf("send() err = %v", err)
+	}
+
+	if len(bodies) != 2 || bodies[0] != "7 content" || bodies[1] != "7 content" {
+		t.Errorf("send() bodies = %q", bodies)
+	}
+}
+
+func TestClient_send_retryUnseekable(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer svr.Close()
+
+	c, _ := retryClient(svr.URL, 1)
+
+	req := &request{
+		method:     http.MethodPost,
+		name:       "upload asset",
+		reader:     io.MultiReader(),
+		url:        svr.URL,
+		idempotent: true,
+	}
+	if err := c.send(t.Context(), req, nil); err == nil || err.Error() != "upload asset: retry: request body can't be rewound" {
+		t.Errorf("send() err = %v", err)
+	}
+}
+
+func TestClient_send_retryPost(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []func(http.ResponseWriter)
+		created   bool
+		error     string
+	}{
+		{
+			name: "server error",
+			responses: []func(http.ResponseWriter){
+				status(http.StatusBadGateway, nil),
+			},
+			error: "releases failed: 502 Bad Gateway",
+		},
+		{
+			name: "rate limited",
+			responses: []func(http.ResponseWriter){
+				status(http.StatusTooManyRequests, map[string]string{"Retry-After": "1"}),
+				status(http.StatusOK, nil),
+			},
+		},
+		{
+			name: "server error, not created",
+			responses: []func(http.ResponseWriter){
+				status(http.StatusBadGateway, nil),
+				status(http.StatusOK, nil),
+			},
+			created: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				if calls >= len(tt.responses) {
+					t.Errorf("unexpected request %d", calls+1)
+					w.WriteHeader(http.StatusTeapot)
+					return
+				}
+				tt.responses[calls](w)
+				calls++
+			}))
+			defer svr.Close()
+
+			c, _ := retryClient(svr.URL, 3)
+
+			var checks int
+			req := &request{
+				method: http.MethodPost,
+				name:   "releases",
+				url:    svr.URL,
+			}
+			if tt.created {
+				req.created = func(context.Context, any) (bool, error) {
+					checks++
+					return false, nil
+				}
+			}
+
+			err := c.send(t.Context(), req, nil)
+			if tt.error != "" {
+				if err == nil || err.Error() != tt.error {
+					t.Fatalf("send() err = %v, want %s", err, tt.error)
+				}
+			} else if err != nil {
+				t.Fatalf("send() err = %v", err)
+			}
+
+			if calls != len(tt.responses) {
+				t.Errorf("send() requests = %d, want %d", calls, len(tt.responses))
+			}
+
+			if tt.created && checks != 1 {
+				t.Errorf("send() checks = %d, want 1", checks)
+			}
+		})
+	}
+}
+
+func TestClient_send_retryPostUnsent(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	svr.Close()
+
+	c, waits := retryClient(svr.URL, 2)
+
+	req := &request{
+		method: http.MethodPost,
+		name:   "releases",
+		url:    svr.URL,
+	}
+	if err := c.send(t.Context(), req, nil); err == nil {
+		t.Fatal("send() err = nil")
+	}
+
+	if len(*waits) != 2 {
+		t.Errorf("send() waits = %v, want 2", *waits)
+	}
+}
+
+func status(code int, headers map[string]string) func(http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		for k, v := range headers {
+			w.Header().Set(k, v)
+		}
+		w.WriteHeader(code)
+		if code == http.StatusOK {
+			_, _ = w.Write([]byte(`{"sha":"abc"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"message":"` + strconv.Itoa(code) + ` ` + http.StatusText(code) + `"}`))
+	}
+}
+
+func TestClient_send_timeout(t *testing.T) {
+	var calls atomic.Int32
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if calls.Add(1) == 1 {
+			<-req.Context().Done()
+			return
+		}
+		status(http.StatusOK, nil)(w)
+	}))
+	defer svr.Close()
+
+	c, _ := retryClient(svr.URL, 1)
+	c.timeout = 50 * time.Millisecond
+
+	if got, err := c.Head(t.Context()); err != nil || got != "abc" {
+		t.Errorf("Head() got = %q %v", got, err)
+	}
+
+	if n := calls.Load(); n != 2 {
+		t.Errorf("Head() requests = %d, want 2", n)
+	}
+}
+
+func TestClient_send_canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+
+	var calls int
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		cancel()
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer svr.Close()
+
+	c, _ := retryClient(svr.URL, 3)
+	c.sleep = sleep
+
+	if _, err := c.Head(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Head() err = %v, want %v", err, context.Canceled)
+	}
+
+	if calls != 1 {
+		t.Errorf("Head() requests = %d, want 1", calls)
+	}
+}
@@ -0,0 +1,180 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/agukrapo/tagger/versions"
+)
+
+const (
+	defaultTaggerName  = "github-actions[bot]"
+	defaultTaggerEmail = "41898282+github-actions[bot]@users.noreply.github.com"
+)
+
+type TagOptions struct {
+	Annotated bool
+	Message   string
+	Tagger    string
+	Now       func() time.Time
+}
+
+func (o TagOptions) tagger() (string, string) {
+	if addr, err := mail.ParseAddress(o.Tagger); err == nil {
+		return addr.Name, addr.Address
+	}
+
+	return defaultTaggerName, defaultTaggerEmail
+}
+
+func (o TagOptions) now() time.Time {
+	if o.Now != nil {
+		return o.Now()
+	}
+
+	return time.Now()
+}
+
+func (c *Client) tagMessage(changes versions.Changes) (string, error) {
+	if c.tag.Message != "" {
+		return strings.ReplaceAll(c.tag.Message, "{version}", changes.Version.String()), nil
+	}
+
+	out, err := c.changeLog.Render(changes)
+	if err != nil {
+		return "", err
+	}
+
+	if strings.TrimSpace(out) == "" {
+		return changes.Version.String(), nil
+	}
+
+	return out, nil
+}
+
+func (c *Client) tagBody(changes versions.Changes, message string) string {
+	name, email := c.tag.tagger()
+
+	return fmt.Sprintf(`{"tag":%q,"message":%q,"object":%q,"type":"commit","tagger":{"name":%q,"email":%q,"date":%q}}`,
+		changes.Version, message, changes.SHA, name, email, c.tag.now().UTC().Format(time.RFC3339))
+}
+
+func refBody(version versions.Version, sha string) string {
+	return fmt.Sprintf(`{"ref":"refs/tags/%s","sha":%q}`, version, sha)
+}
+
+type objectResponse struct {
+	SHA string `json:"sha"`
+}
+
+func (c *Client) Push(ctx context.Context, changes versions.Changes) error {
+	if changes.SHA == "" {
+		return fmt.Errorf("tag %s: unknown commit", changes.Version)
+	}
+
+	sha := changes.SHA
+
+	if c.tag.Annotated {
+		message, err := c.tagMessage(changes)
+		if err != nil {
+			return err
+		}
+
+		body := c.tagBody(changes, message)
+
+		req := &request{
+			method: http.MethodPost,
+			reader: strings.NewReader(body),
+			name:   "tag object",
+			body:   body,
+			url:    c.url("git/tags"),
+			// a duplicate tag object stays unreferenced
+			idempotent: true,
+		}
+
+		var tag objectResponse
+		if err := c.send(ctx, req, &tag); err != nil {
+			return err
+		}
+		sha = tag.SHA
+	}
+
+	body := refBody(changes.Version, sha)
+
+	req := &request{
+		method: http.MethodPost,
+		reader: strings.NewReader(body),
+		name:   "tag ref",
+		body:   body,
+		url:    c.url("git/refs"),
+		created: func(ctx context.Context, _ any) (bool, error) {
+			return c.tagRef(ctx, changes.Version, sha)
+		},
+	}
+
+	return c.send(ctx, req, nil)
+}
+
+type refResponse struct {
+	Ref    string         `json:"ref"`
+	Object objectResponse `json:"object"`
+}
+
+// tagRef tells whether the tag ref exists and points to sha.
+func (c *Client) tagRef(ctx context.Context, version versions.Version, sha string) (bool, error) {
+	req := &request{
+		method: http.MethodGet,
+		name:   "matching refs",
+		url:    c.url("git/matching-refs/tags/" + version.String()),
+	}
+
+	var refs []refResponse
+	if err := c.send(ctx, req, &refs); err != nil {
+		return false, err
+	}
+
+	for _, ref := range refs {
+		if ref.Ref == "refs/tags/"+version.String() {
+			return ref.Object.SHA == sha, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (c *Client) DeleteTag(ctx context.Context, changes versions.Changes) error {
+	req := &request{
+		method: http.MethodDelete,
+		name:   "delete tag ref",
+		url:    c.url("git/refs/tags/" + changes.Version.String()),
+	}
+
+	return c.send(ctx, req, nil)
+}
+
+func (d DryRun) DeleteTag(_ context.Context, changes versions.Changes) error {
+	fmt.Printf("[dry-run] DELETE %s\n", d.client.url("git/refs/tags/"+changes.Version.String()))
+	return nil
+}
+
+func (d DryRun) Push(_ context.Context, changes versions.Changes) error {
+	sha := changes.SHA
+
+	if d.client.tag.Annotated {
+		message, err := d.client.tagMessage(changes)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("[dry-run] POST %s\n%s\n", d.client.url("git/tags"), d.client.tagBody(changes, message))
+		sha = "<tag object sha>"
+	}
+
+	fmt.Printf("[dry-run] POST %s\n%s\n", d.client.url("git/refs"), refBody(changes.Version, sha))
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package github
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agukrapo/tagger/changelog"
+	"github.com/agukrapo/tagger/versions"
+)
+
+func TestClient_Push(t *testing.T) {
+	now := func() time.Time { return time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	tests := []struct {
+		name string
+		tag  TagOptions
+		want []string
+	}{
+		{
+			name: "lightweight",
+			want: []string{
+				`/repos/o/r/git/refs {"ref":"refs/tags/v0","sha":"abc"}`,
+			},
+		},
+		{
+			name: "annotated",
+			tag:  TagOptions{Annotated: true, Message: "release {version}", Tagger: "Bot <bot@example.com>", Now: now},
+			want: []string{
+				`/repos/o/r/git/tags {"tag":"v0","message":"release v0","object":"abc","type":"commit","tagger":{"name":"Bot","email":"bot@example.com","date":"2025-01-02T03:04:05Z"}}`,
+				`/repos/o/r/git/refs {"ref":"refs/tags/v0","sha":"def"}`,
+			},
+		},
+		{
+			name: "annotated with changelog",
+			tag:  TagOptions{Annotated: true, Now: now},
+			want: []string{
+				`/repos/o/r/git/tags {"tag":"v0","message":"#### Bug fixes:\n- [b](https://github.com/o/r/commit/abc)\n","object":"abc","type":"commit","tagger":{"name":"github-actions[bot]","email":"41898282+github-actions[bot]@users.noreply.github.com","date":"2025-01-02T03:04:05Z"}}`,
+				`/repos/o/r/git/refs {"ref":"refs/tags/v0","sha":"def"}`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				raw, _ := io.ReadAll(req.Body)
+				got = append(got, req.URL.Path+" "+string(raw))
+
+				if req.Method != http.MethodPost {
+					t.Errorf("unexpected method %s", req.Method)
+				}
+				w.WriteHeader(http.StatusCreated)
+				if req.URL.Path == "/repos/o/r/git/tags" {
+					_, _ = w.Write([]byte(`{"sha":"def"}`))
+				}
+			}))
+			defer svr.Close()
+
+			c := New("o", "r", svr.URL, "", Options{
+				Tag:       tt.tag,
+				ChangeLog: changelog.New(changelog.Options{URLs: NewLinks("", "o", "r")}),
+			})
+
+			changes := versions.Changes{SHA: "abc", Commits: []*versions.Commit{versions.NewCommit("abc", "fix: b")}}
+			if err := c.Push(t.Context(), changes); err != nil {
+				t.Fatalf("Push() err = %v", err)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("Push() requests = %q, want %q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Push() request %d = %s, want %s", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestClient_Push_createdDespiteError(t *testing.T) {
+	tests := []struct {
+		name  string
+		refs  string
+		want  []string
+		error string
+	}{
+		{
+			name: "created",
+			refs: `[{"ref":"refs/tags/v0","object":{"sha":"abc"}}]`,
+			want: []string{"POST /repos/o/r/git/refs", "GET /repos/o/r/git/matching-refs/tags/v0"},
+		},
+		{
+			name:  "not created",
+			refs:  `[{"ref":"refs/tags/v0.1","object":{"sha":"abc"}}]`,
+			want:  []string{"POST /repos/o/r/git/refs", "GET /repos/o/r/git/matching-refs/tags/v0", "POST /repos/o/r/git/refs"},
+			error: "tag ref failed: 502 Bad Gateway",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				got = append(got, req.Method+" "+req.URL.Path)
+
+				if req.Method == http.MethodPost {
+					w.WriteHeader(http.StatusBadGateway)
+					return
+				}
+				_, _ = w.Write([]byte(tt.refs))
+			}))
+			defer svr.Close()
+
+			c := New("o", "r", svr.URL, "", Options{Retry: RetryOptions{Retries: 1}})
+			c.sleep = func(context.Context, time.Duration) error { return nil }
+
+			err := c.Push(t.Context(), versions.Changes{SHA: "abc"})
+			if tt.error != "" {
+				if err == nil || err.Error() != tt.error {
+					t.Fatalf("Push() err = %v, want %s", err, tt.error)
+				}
+			} else if err != nil {
+				t.Fatalf("Push() err = %v", err)
+			}
+
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Push() requests = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_Push_unknownCommit(t *testing.T) {
+	if err := New("o", "r", "", "", Options{}).Push(t.Context(), versions.Changes{}); err == nil {
+		t.Error("Push() err = nil")
+	}
+}
+
+func TestClient_DeleteTag(t *testing.T) {
+	var got string
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		got = req.Method + " " + req.URL.Path
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer svr.Close()
+
+	if err := New("o", "r", svr.URL, "", Options{}).DeleteTag(t.Context(), versions.Changes{}); err != nil {
+		t.Fatalf("DeleteTag() err = %v", err)
+	}
+
+	if want := "DELETE /repos/o/r/git/refs/tags/v0"; got != want {
+		t.Errorf("DeleteTag() request = %s, want %s", got, want)
+	}
+}
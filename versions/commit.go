@@ -0,0 +1,261 @@
+package versions
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type Change uint8
+
+const (
+	None Change = iota
+	Breaking
+	Feat
+	Fix
+)
+
+func (c Change) String() string {
+	return [...]string{"none", "breaking", "feat", "fix"}[c]
+}
+
+type Footer struct {
+	token, value string
+}
+
+func (f Footer) Token() string {
+	return f.token
+}
+
+func (f Footer) Value() string {
+	return f.value
+}
+
+func (f Footer) breaking() bool {
+	return f.token == "BREAKING CHANGE" || f.token == "BREAKING-CHANGE"
+}
+
+type Header struct {
+	Type, Scope, Description string
+	Breaking                 bool
+}
+
+func ParseHeader(subject string) (Header, error) {
+	var out Header
+
+	prefix, description, ok := strings.Cut(subject, ":")
+	if !ok {
+		return Header{}, errors.New("missing ':' after type")
+	}
+
+	if !strings.HasPrefix(description, " ") {
+		return Header{}, errors.New("missing space after ':'")
+	}
+
+	out.Description = strings.TrimSpace(description)
+	if out.Description == "" {
+		return Header{}, errors.New("empty description")
+	}
+
+	prefix, out.Breaking = strings.CutSuffix(prefix, "!")
+
+	if typ, scope, ok := strings.Cut(prefix, "("); ok {
+		scope, ok = strings.CutSuffix(scope, ")")
+		if !ok || strings.ContainsAny(scope, "()") {
+			return Header{}, fmt.Errorf("invalid scope in %q", prefix)
+		}
+
+		if strings.TrimSpace(scope) == "" {
+			return Header{}, errors.New("empty scope")
+		}
+
+		prefix, out.Scope = typ, scope
+	}
+
+	if prefix == "" {
+		return Header{}, errors.New("missing type")
+	}
+
+	if !commitType.MatchString(prefix) {
+		return Header{}, fmt.Errorf("invalid type %q", prefix)
+	}
+
+	out.Type = prefix
+
+	return out, nil
+}
+
+var commitType = regexp.MustCompile(`^[A-Za-z]+$`)
+
+type PullRequest struct {
+	Number int
+	Title  string
+}
+
+type Commit struct {
+	sha, subject, body string
+	footers            []Footer
+
+	header    Header
+	headerErr error
+
+	author            string
+	pullRequest       PullRequest
+	firstContribution bool
+}
+
+func NewCommit(sha, message string) *Commit {
+	subject, rest, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	body, footers := parseFooters(rest)
+	header, err := ParseHeader(strings.TrimSpace(subject))
+
+	return &Commit{
+		sha:       sha,
+		subject:   strings.TrimSpace(subject),
+		body:      body,
+		footers:   footers,
+		header:    header,
+		headerErr: err,
+	}
+}
+
+func (c *Commit) SHA() string {
+	return c.sha
+}
+
+func (c *Commit) Subject() string {
+	return c.subject
+}
+
+func (c *Commit) Body() string {
+	return c.body
+}
+
+func (c *Commit) Footers() []Footer {
+	return c.footers
+}
+
+func (c *Commit) Author() string {
+	return c.author
+}
+
+func (c *Commit) SetAuthor(login string) {
+	c.author = login
+}
+
+func (c *Commit) PullRequest() PullRequest {
+	return c.pullRequest
+}
+
+func (c *Commit) SetPullRequest(pr PullRequest) {
+	c.pullRequest = pr
+}
+
+func (c *Commit) FirstContribution() bool {
+	return c.firstContribution
+}
+
+func (c *Commit) SetFirstContribution(first bool) {
+	c.firstContribution = first
+}
+
+const ReleaseFooter = "Tagger-Release"
+
+func (c *Commit) IsRelease() bool {
+	for _, footer := range c.footers {
+		if footer.token == ReleaseFooter {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *Commit) BreakingNote() string {
+	var notes []string
+	for _, footer := range c.footers {
+		if footer.breaking() {
+			notes = append(notes, footer.value)
+		}
+	}
+
+	return strings.Join(notes, "\n")
+}
+
+func (c *Commit) Header() (Header, error) {
+	return c.header, c.headerErr
+}
+
+func (c *Commit) Change() (Change, string) {
+	if c.headerErr != nil {
+		if c.BreakingNote() != "" {
+			return Breaking, c.subject
+		}
+		return None, c.subject
+	}
+
+	msg := c.header.Description
+	if c.header.Breaking || c.BreakingNote() != "" {
+		return Breaking, msg
+	}
+
+	if strings.EqualFold(c.header.Type, "feat") {
+		return Feat, msg
+	}
+
+	if strings.EqualFold(c.header.Type, "fix") {
+		return Fix, msg
+	}
+
+	return None, msg
+}
+
+type Violation struct {
+	Commit *Commit
+	Reason error
+}
+
+func Lint(commits []*Commit) []Violation {
+	var out []Violation
+	for _, commit := range commits {
+		if _, err := commit.Header(); err != nil {
+			out = append(out, Violation{commit, err})
+		}
+	}
+
+	return out
+}
+
+var footerLine = regexp.MustCompile(`^(BREAKING CHANGE|[\w-]+)(: | #)(.*)$`)
+
+func parseFooters(text string) (string, []Footer) {
+	paragraphs := strings.Split(strings.ReplaceAll(strings.TrimSpace(text), "\r\n", "\n"), "\n\n")
+
+	start := len(paragraphs)
+	for i := len(paragraphs) - 1; i >= 0; i-- {
+		first, _, _ := strings.Cut(strings.TrimSpace(paragraphs[i]), "\n")
+		if !footerLine.MatchString(first) {
+			break
+		}
+		start = i
+	}
+
+	var footers []Footer
+	for _, line := range strings.Split(strings.Join(paragraphs[start:], "\n\n"), "\n") {
+		if matches := footerLine.FindStringSubmatch(line); matches != nil {
+			footers = append(footers, Footer{matches[1], matches[3]})
+			continue
+		}
+
+		if len(footers) != 0 {
+			footers[len(footers)-1].value += "\n" + line
+		}
+	}
+
+	for i := range footers {
+		footers[i].value = strings.TrimSpace(footers[i].value)
+	}
+
+	return strings.TrimSpace(strings.Join(paragraphs[:start], "\n\n")), footers
+}
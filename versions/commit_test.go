@@ -0,0 +1,294 @@
+package versions
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCommit_Change(t *testing.T) {
+	tests := []struct {
+		name  string
+		msg   string
+		want1 Change
+		want2 string
+	}{
+		{
+			"Breaking",
+			"chore!: drop support for Node 6",
+			Breaking,
+			"drop support for Node 6",
+		},
+		{
+			"Breaking (scope)",
+			"feat(api)!: ASD123: send an email to the customer when a product is shipped",
+			Breaking,
+			"ASD123: send an email to the customer when a product is shipped",
+		},
+		{
+			"Feat",
+			"feat: allow provided config object to extend other configs",
+			Feat,
+			"allow provided config object to extend other configs",
+		},
+		{
+			"Feat (scope)",
+			"feat(lang): add Polish language",
+			Feat,
+			"add Polish language",
+		},
+		{
+			"Fix",
+			"fix: qwerty:prevent racing of requests",
+			Fix,
+			"qwerty:prevent racing of requests",
+		},
+		{
+			"Fix (scope)",
+			"fix(lang): prevent racing of requests",
+			Fix,
+			"prevent racing of requests",
+		},
+		{
+			"None",
+			"docs: correct spelling of CHANGELOG",
+			None,
+			"correct spelling of CHANGELOG",
+		},
+		{
+			"None (scope)",
+			"docs(lang): update ref docs",
+			None,
+			"update ref docs",
+		},
+		{
+			"None (feat prefix)",
+			"feature-flag: x",
+			None,
+			"feature-flag: x",
+		},
+		{
+			"None (fix prefix)",
+			"fixup: y",
+			None,
+			"y",
+		},
+		{
+			"None (fix prefix, scope)",
+			"fixture(db): z",
+			None,
+			"z",
+		},
+		{
+			"None (revert)",
+			`Revert "fix: tree tag type"`,
+			None,
+			`Revert "fix: tree tag type"`,
+		},
+		{
+			"Feat (uppercase)",
+			"FEAT: shout",
+			Feat,
+			"shout",
+		},
+		{
+			"Breaking (footer)",
+			"feat: allow provided config object to extend other configs\n\nBREAKING CHANGE: `extends` key in config file is now used for extending other config files",
+			Breaking,
+			"allow provided config object to extend other configs",
+		},
+		{
+			"Breaking (hyphen footer)",
+			"refactor(runtime): drop special-casing\n\nSome body text.\n\nBREAKING-CHANGE: environments are no longer merged",
+			Breaking,
+			"drop special-casing",
+		},
+		{
+			"Not breaking (body mention)",
+			"fix: handle BREAKING CHANGE: in body\n\nThe text BREAKING CHANGE: inside a paragraph is not a footer.\nMore text.",
+			Fix,
+			"handle BREAKING CHANGE: in body",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commit := NewCommit("", tt.msg)
+			if got1, got2 := commit.Change(); got1 != tt.want1 || got2 != tt.want2 {
+				t.Errorf("Change() = %v %v, want %v %v", got1, got2, tt.want1, tt.want2)
+			}
+		})
+	}
+}
+
+func TestParseHeader(t *testing.T) {
+	tests := []struct {
+		subject string
+		want    Header
+		error   string
+	}{
+		{
+			subject: "feat: add Polish language",
+			want:    Header{Type: "feat", Description: "add Polish language"},
+		},
+		{
+			subject: "feat(lang): add Polish language",
+			want:    Header{Type: "feat", Scope: "lang", Description: "add Polish language"},
+		},
+		{
+			subject: "feat(api)!: ASD123: send an email",
+			want:    Header{Type: "feat", Scope: "api", Breaking: true, Description: "ASD123: send an email"},
+		},
+		{
+			subject: "chore!: drop support for Node 6",
+			want:    Header{Type: "chore", Breaking: true, Description: "drop support for Node 6"},
+		},
+		{
+			subject: "fixture(db): seed users",
+			want:    Header{Type: "fixture", Scope: "db", Description: "seed users"},
+		},
+		{
+			subject: "feat(ui/forms): trim input  ",
+			want:    Header{Type: "feat", Scope: "ui/forms", Description: "trim input"},
+		},
+		{
+			subject: "add Polish language",
+			error:   "missing ':' after type",
+		},
+		{
+			subject: "feat:add Polish language",
+			error:   "missing space after ':'",
+		},
+		{
+			subject: "feat: ",
+			error:   "empty description",
+		},
+		{
+			subject: ": add Polish language",
+			error:   "missing type",
+		},
+		{
+			subject: "(lang): add Polish language",
+			error:   "missing type",
+		},
+		{
+			subject: "feat(): add Polish language",
+			error:   "empty scope",
+		},
+		{
+			subject: "feat(lang: add Polish language",
+			error:   `invalid scope in "feat(lang"`,
+		},
+		{
+			subject: "feat(a)(b): add Polish language",
+			error:   `invalid scope in "feat(a)(b)"`,
+		},
+		{
+			subject: "feature-flag: x",
+			error:   `invalid type "feature-flag"`,
+		},
+		{
+			subject: "feat !: x",
+			error:   `invalid type "feat "`,
+		},
+		{
+			subject: "fix!(lang): x",
+			error:   `invalid type "fix!"`,
+		},
+		{
+			subject: `Revert "fix: tree tag type"`,
+			error:   `invalid type "Revert \"fix"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.subject, func(t *testing.T) {
+			got, err := ParseHeader(tt.subject)
+			if errNotEqual(tt.error, err) {
+				t.Fatalf("ParseHeader() err = %v, error %v", err, tt.error)
+			}
+			if got != tt.want {
+				t.Errorf("ParseHeader() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewCommit(t *testing.T) {
+	tests := []struct {
+		name    string
+		msg     string
+		subject string
+		body    string
+		footers []Footer
+		note    string
+	}{
+		{
+			name:    "subject only",
+			msg:     "fix: prevent racing of requests\n",
+			subject: "fix: prevent racing of requests",
+		},
+		{
+			name:    "body",
+			msg:     "fix: prevent racing of requests\n\nIntroduce a request id.\n\nRemove timeouts.",
+			subject: "fix: prevent racing of requests",
+			body:    "Introduce a request id.\n\nRemove timeouts.",
+		},
+		{
+			name:    "body and footers",
+			msg:     "fix: prevent racing of requests\n\nIntroduce a request id.\n\nReviewed-by: Z\nRefs #123",
+			subject: "fix: prevent racing of requests",
+			body:    "Introduce a request id.",
+			footers: []Footer{{"Reviewed-by", "Z"}, {"Refs", "123"}},
+		},
+		{
+			name:    "multiline breaking footer",
+			msg:     "feat!: drop Node 6\n\nBREAKING CHANGE: Node 6 is EOL,\nupgrade to Node 8.\nRefs: #42",
+			subject: "feat!: drop Node 6",
+			footers: []Footer{{"BREAKING CHANGE", "Node 6 is EOL,\nupgrade to Node 8."}, {"Refs", "#42"}},
+			note:    "Node 6 is EOL,\nupgrade to Node 8.",
+		},
+		{
+			name:    "footer like body paragraph",
+			msg:     "docs: update\n\nNote: this is body.\n\nThis paragraph is body too.",
+			subject: "docs: update",
+			body:    "Note: this is body.\n\nThis paragraph is body too.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewCommit("sha", tt.msg)
+			if got.Subject() != tt.subject {
+				t.Errorf("Subject() = %q, want %q", got.Subject(), tt.subject)
+			}
+			if got.Body() != tt.body {
+				t.Errorf("Body() = %q, want %q", got.Body(), tt.body)
+			}
+			if !reflect.DeepEqual(got.Footers(), tt.footers) {
+				t.Errorf("Footers() = %q, want %q", got.Footers(), tt.footers)
+			}
+			if got.BreakingNote() != tt.note {
+				t.Errorf("BreakingNote() = %q, want %q", got.BreakingNote(), tt.note)
+			}
+		})
+	}
+}
+
+func TestLint(t *testing.T) {
+	commits := []*Commit{
+		NewCommit("a", "feat: ok"),
+		NewCommit("b", "wip"),
+		NewCommit("c", "fix(db):no space"),
+		NewCommit("d", "docs(readme)!: ok too"),
+	}
+
+	got := Lint(commits)
+	if len(got) != 2 {
+		t.Fatalf("Lint() len(got) = %d, want 2", len(got))
+	}
+
+	if got[0].Commit.SHA() != "b" || got[0].Reason.Error() != "missing ':' after type" {
+		t.Errorf("Lint() got[0] = %v %v", got[0].Commit.SHA(), got[0].Reason)
+	}
+
+	if got[1].Commit.SHA() != "c" || got[1].Reason.Error() != "missing space after ':'" {
+		t.Errorf("Lint() got[1] = %v %v", got[1].Commit.SHA(), got[1].Reason)
+	}
+}
@@ -0,0 +1,129 @@
+package versions
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type Bump uint8
+
+const (
+	NoBump Bump = iota
+	PatchBump
+	MinorBump
+	MajorBump
+)
+
+func (b Bump) String() string {
+	return [...]string{"none", "patch", "minor", "major"}[b]
+}
+
+func ParseBump(in string) (Bump, error) {
+	for _, b := range []Bump{NoBump, PatchBump, MinorBump, MajorBump} {
+		if strings.EqualFold(in, b.String()) {
+			return b, nil
+		}
+	}
+
+	return NoBump, fmt.Errorf("invalid bump %q", in)
+}
+
+const (
+	BreakingSection = "Breaking changes"
+	OtherSection    = "Other"
+)
+
+type Rule struct {
+	Type    string
+	Bump    Bump
+	Section string
+}
+
+func (r Rule) Validate() error {
+	if r.Type == "" {
+		return errors.New("type is required")
+	}
+
+	if !commitType.MatchString(r.Type) {
+		return fmt.Errorf("invalid type %q", r.Type)
+	}
+
+	return nil
+}
+
+type Rules []Rule
+
+func DefaultRules() Rules {
+	return Rules{
+		{Type: "feat", Bump: MinorBump, Section: "New features"},
+		{Type: "fix", Bump: PatchBump, Section: "Bug fixes"},
+	}
+}
+
+// Set overrides the rule of the same type in place, keeping its section when the override has none, or appends a new one.
+func (r Rules) Set(rule Rule) Rules {
+	out := slices.Clone(r)
+	for i, existing := range out {
+		if strings.EqualFold(existing.Type, rule.Type) {
+			rule.Section = cmp.Or(rule.Section, existing.Section)
+			out[i] = rule
+			return out
+		}
+	}
+
+	return append(out, rule)
+}
+
+func (r Rules) lookup(commit *Commit) (Rule, bool) {
+	header, err := commit.Header()
+	if err != nil {
+		return Rule{}, false
+	}
+
+	for _, rule := range r {
+		if strings.EqualFold(rule.Type, header.Type) {
+			return rule, true
+		}
+	}
+
+	return Rule{}, false
+}
+
+func (r Rules) Bump(commit *Commit) Bump {
+	if change, _ := commit.Change(); change == Breaking {
+		return MajorBump
+	}
+
+	rule, _ := r.lookup(commit)
+	return rule.Bump
+}
+
+func (r Rules) Section(commit *Commit) string {
+	if change, _ := commit.Change(); change == Breaking {
+		return BreakingSection
+	}
+
+	if rule, ok := r.lookup(commit); ok && rule.Section != "" {
+		return rule.Section
+	}
+
+	return OtherSection
+}
+
+func (r Rules) Sections() []string {
+	out := []string{BreakingSection}
+	for _, rule := range r {
+		if rule.Section != "" && !slices.Contains(out, rule.Section) {
+			out = append(out, rule.Section)
+		}
+	}
+
+	if !slices.Contains(out, OtherSection) {
+		out = append(out, OtherSection)
+	}
+
+	return out
+}
@@ -0,0 +1,62 @@
+package versions
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRules(t *testing.T) {
+	custom := DefaultRules().
+		Set(Rule{Type: "perf", Bump: PatchBump, Section: "Performance"}).
+		Set(Rule{Type: "deps", Bump: PatchBump}).
+		Set(Rule{Type: "fix", Bump: PatchBump, Section: "Fixes"})
+
+	tests := []struct {
+		name    string
+		rules   Rules
+		msg     string
+		bump    Bump
+		section string
+	}{
+		{"default feat", DefaultRules(), "feat: x", MinorBump, "New features"},
+		{"default fix", DefaultRules(), "fix(db): x", PatchBump, "Bug fixes"},
+		{"default perf", DefaultRules(), "perf: x", NoBump, OtherSection},
+		{"default breaking", DefaultRules(), "docs!: x", MajorBump, BreakingSection},
+		{"default invalid", DefaultRules(), "update readme", NoBump, OtherSection},
+		{"custom perf", custom, "perf: x", PatchBump, "Performance"},
+		{"custom deps", custom, "DEPS: bump x", PatchBump, OtherSection},
+		{"custom fix", custom, "fix: x", PatchBump, "Fixes"},
+		{"custom breaking footer", custom, "deps: x\n\nBREAKING CHANGE: y", MajorBump, BreakingSection},
+		{"override without section", DefaultRules().Set(Rule{Type: "feat", Bump: MajorBump}), "feat: x", MajorBump, "New features"},
+		{"override to none", DefaultRules().Set(Rule{Type: "fix", Bump: NoBump}), "fix: x", NoBump, "Bug fixes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commit := NewCommit("", tt.msg)
+			if got := tt.rules.Bump(commit); got != tt.bump {
+				t.Errorf("Bump() = %v, want %v", got, tt.bump)
+			}
+			if got := tt.rules.Section(commit); got != tt.section {
+				t.Errorf("Section() = %v, want %v", got, tt.section)
+			}
+		})
+	}
+
+	want := []string{BreakingSection, "New features", "Fixes", "Performance", OtherSection}
+	if got := custom.Sections(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Sections() = %v, want %v", got, want)
+	}
+}
+
+func TestParseBump(t *testing.T) {
+	for _, want := range []Bump{NoBump, PatchBump, MinorBump, MajorBump} {
+		got, err := ParseBump(want.String())
+		if err != nil || got != want {
+			t.Errorf("ParseBump(%q) = %v %v, want %v", want, got, err, want)
+		}
+	}
+
+	if _, err := ParseBump("huge"); err == nil || err.Error() != `invalid bump "huge"` {
+		t.Errorf("ParseBump() err = %v", err)
+	}
+}
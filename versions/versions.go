@@ -1,16 +1,33 @@
 package versions
 
 import (
+	"cmp"
+	"context"
+	"errors"
 	"fmt"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 )
 
 type Version struct {
 	major, minor, patch int
+	pre                 []string
+
+	// tag is the tag the version was parsed from, so existing tags are referenced as spelled.
+	tag Tag
 }
 
 func (v Version) String() string {
+	if v.tag != "" {
+		return string(v.tag)
+	}
+
+	if v.IsPreRelease() {
+		return fmt.Sprintf("v%d.%d.%d-%s", v.major, v.minor, v.patch, strings.Join(v.pre, "."))
+	}
+
 	var patch string
 	if v.patch != 0 {
 		patch = fmt.Sprintf(".%d", v.patch)
@@ -24,20 +41,134 @@ func (v Version) String() string {
 	return fmt.Sprintf("v%d%s", v.major, minor)
 }
 
+func (v Version) Major() int {
+	return v.major
+}
+
+func (v Version) Minor() int {
+	return v.minor
+}
+
+func (v Version) Patch() int {
+	return v.patch
+}
+
+func (v Version) IsPreRelease() bool {
+	return len(v.pre) != 0
+}
+
+func (v Version) core() Version {
+	return Version{major: v.major, minor: v.minor, patch: v.patch}
+}
+
 func (v Version) bump(major, minor, patch bool) Version {
+	if v.IsPreRelease() {
+		switch {
+		case major && (v.minor != 0 || v.patch != 0):
+			return Version{major: v.major + 1}
+		case minor && v.patch != 0:
+			return Version{major: v.major, minor: v.minor + 1}
+		case major || minor || patch:
+			return v.core()
+		}
+
+		return v
+	}
+
 	if major {
-		return Version{v.major + 1, 0, 0}
+		return Version{major: v.major + 1}
 	} else if minor {
-		return Version{v.major, v.minor + 1, 0}
+		return Version{major: v.major, minor: v.minor + 1}
 	} else if patch {
-		return Version{v.major, v.minor, v.patch + 1}
+		return Version{major: v.major, minor: v.minor, patch: v.patch + 1}
 	}
 
 	return v
 }
 
+func (v Version) bumpPreRelease(major, minor, patch bool, channel string) Version {
+	next := v.bump(major, minor, patch)
+	if next.equals(v) {
+		return v
+	}
+
+	ids := strings.Split(channel, ".")
+	if counter, ok := v.counter(ids); ok && next.equals(v.core()) {
+		return next.withPreRelease(ids, counter+1)
+	}
+
+	return next.withPreRelease(ids, 1)
+}
+
+func (v Version) counter(channel []string) (int, bool) {
+	if len(v.pre) != len(channel)+1 || !slices.Equal(v.pre[:len(channel)], channel) {
+		return 0, false
+	}
+
+	n, err := strconv.Atoi(v.pre[len(channel)])
+	if err != nil {
+		return 0, false
+	}
+
+	return n, true
+}
+
+func (v Version) withPreRelease(channel []string, counter int) Version {
+	v.pre, v.tag = append(slices.Clone(channel), strconv.Itoa(counter)), ""
+	return v
+}
+
 func (v Version) equals(other Version) bool {
-	return v.major == other.major && v.minor == other.minor && v.patch == other.patch
+	return v.major == other.major && v.minor == other.minor && v.patch == other.patch && slices.Equal(v.pre, other.pre)
+}
+
+func (v Version) Compare(other Version) int {
+	if c := cmp.Compare(v.major, other.major); c != 0 {
+		return c
+	}
+
+	if c := cmp.Compare(v.minor, other.minor); c != 0 {
+		return c
+	}
+
+	if c := cmp.Compare(v.patch, other.patch); c != 0 {
+		return c
+	}
+
+	switch {
+	case !v.IsPreRelease() && !other.IsPreRelease():
+		return 0
+	case !v.IsPreRelease():
+		return 1
+	case !other.IsPreRelease():
+		return -1
+	}
+
+	for i := 0; i < len(v.pre) && i < len(other.pre); i++ {
+		if c := compareIdentifiers(v.pre[i], other.pre[i]); c != 0 {
+			return c
+		}
+	}
+
+	return cmp.Compare(len(v.pre), len(other.pre))
+}
+
+func compareIdentifiers(a, b string) int {
+	aNum, bNum := isNumeric(a), isNumeric(b)
+
+	switch {
+	case aNum && bNum:
+		if c := cmp.Compare(len(a), len(b)); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	case aNum:
+		return -1
+	case bNum:
+		return 1
+	}
+
+	return strings.Compare(a, b)
 }
 
 type Tag string
@@ -47,6 +178,52 @@ func (t Tag) Valid() bool {
 	return err == nil
 }
 
+func Latest(tags []Tag) Tag {
+	var (
+		out    Tag
+		latest Version
+	)
+
+	for _, tag := range tags {
+		version, err := tag.asVersion()
+		if err != nil {
+			continue
+		}
+
+		if out == "" || version.Compare(latest) > 0 {
+			out, latest = tag, version
+		}
+	}
+
+	return out
+}
+
+// Newest returns the valid tags, highest precedence first.
+func Newest(tags []Tag) []Tag {
+	type parsed struct {
+		tag     Tag
+		version Version
+	}
+
+	var valid []parsed
+	for _, tag := range tags {
+		if version, err := tag.asVersion(); err == nil && tag != "" {
+			valid = append(valid, parsed{tag, version})
+		}
+	}
+
+	slices.SortStableFunc(valid, func(a, b parsed) int {
+		return b.version.Compare(a.version)
+	})
+
+	out := make([]Tag, 0, len(valid))
+	for _, p := range valid {
+		out = append(out, p.tag)
+	}
+
+	return out
+}
+
 func (t Tag) asVersion() (Version, error) {
 	if t == "" {
 		return Version{}, nil
@@ -56,7 +233,19 @@ func (t Tag) asVersion() (Version, error) {
 		return Version{}, fmt.Errorf("invalid tag %q", t)
 	}
 
-	chunks := strings.Split(string(t[1:]), ".")
+	core, _, _ := strings.Cut(string(t[1:]), "+")
+
+	core, pre, hasPre := strings.Cut(core, "-")
+
+	var ids []string
+	if hasPre {
+		ids = strings.Split(pre, ".")
+		if !validPreRelease(ids) {
+			return Version{}, fmt.Errorf("invalid tag %q", t)
+		}
+	}
+
+	chunks := strings.Split(core, ".")
 	if len(chunks) == 0 || len(chunks) > 3 {
 		return Version{}, fmt.Errorf("invalid tag %q", t)
 	}
@@ -81,118 +270,293 @@ func (t Tag) asVersion() (Version, error) {
 		}
 	}
 
-	return Version{major, minor, patch}, nil
+	return Version{major: major, minor: minor, patch: patch, pre: ids, tag: t}, nil
 }
 
-type Change uint8
+var preReleaseIdentifier = regexp.MustCompile(`^[0-9A-Za-z-]+$`)
 
-const (
-	None Change = iota
-	Breaking
-	Feat
-	Fix
-)
+func validPreRelease(ids []string) bool {
+	for _, id := range ids {
+		if !preReleaseIdentifier.MatchString(id) {
+			return false
+		}
+
+		if len(id) > 1 && id[0] == '0' && isNumeric(id) {
+			return false
+		}
+	}
 
-func (c Change) String() string {
-	return [...]string{"none", "breaking", "feat", "fix"}[c]
+	return len(ids) != 0
 }
 
-type Commit struct {
-	sha, message string
+func isNumeric(id string) bool {
+	for _, r := range id {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
 }
 
-func NewCommit(sha, message string) *Commit {
-	return &Commit{sha, message}
+type fetcher interface {
+	Tags(ctx context.Context) ([]Tag, error)
+	HeadTags(ctx context.Context) ([]Tag, error)
+	CommitsSince(ctx context.Context, tag Tag) ([]*Commit, error)
+	Head(ctx context.Context) (string, error)
 }
 
-func (c *Commit) SHA() string {
-	return c.sha
+type Changes struct {
+	Tag               Tag
+	Previous, Version Version
+	Commits           []*Commit
+	SHA               string
+
+	// Tagged is set when HEAD already carries Version, e.g. when re-running after a failed release.
+	Tagged bool
 }
 
-func (c *Commit) Change() (Change, string) {
-	chunks := strings.Split(c.message, ":")
-	if len(chunks) == 1 {
-		return None, c.message
-	}
+type pusher interface {
+	Push(context.Context, Changes) error
+}
 
-	msg := strings.TrimSpace(strings.Join(chunks[1:], ":"))
-	if strings.HasSuffix(chunks[0], "!") {
-		return Breaking, msg
-	}
+type ReleaseAsset struct {
+	Name string
+	Size int64
+}
 
-	if strings.HasPrefix(chunks[0], "feat") {
-		return Feat, msg
-	}
+type Release struct {
+	ID        int64
+	URL       string
+	ChangeLog string
+	Assets    []ReleaseAsset
+	Draft     bool
+}
 
-	if strings.HasPrefix(chunks[0], "fix") {
-		return Fix, msg
-	}
+type releaser interface {
+	Release(context.Context, Changes) (Release, error)
+}
 
-	return None, msg
+type tagDeleter interface {
+	DeleteTag(context.Context, Changes) error
 }
 
-type fetcher interface {
-	LatestTag() (Tag, error)
-	CommitsSince(tag Tag) ([]*Commit, error)
+type draftDeleter interface {
+	DeleteDraft(context.Context, Changes) error
 }
 
-type pusher interface {
-	Push(Version) error
+type Options struct {
+	PreRelease string
+	Rules      Rules
+
+	// Rollback deletes the pushed tag and any draft release when the release fails.
+	Rollback bool
 }
 
-type releaser interface {
-	Release(Version, []*Commit) error
+type Result struct {
+	Changes
+
+	Bump    Bump
+	Release Release
+
+	Triggers []*Commit
+	Ignored  []string
 }
 
-func Process(fetcher fetcher, pusher pusher, releaser releaser) error {
-	tag, err := fetcher.LatestTag()
+func Next(ctx context.Context, fetcher fetcher, opts Options) (Result, error) {
+	if opts.PreRelease != "" && !validPreRelease(strings.Split(opts.PreRelease, ".")) {
+		return Result{}, fmt.Errorf("invalid pre-release channel %q", opts.PreRelease)
+	}
+
+	rules := opts.Rules
+	if rules == nil {
+		rules = DefaultRules()
+	}
+
+	tags, err := fetcher.Tags(ctx)
 	if err != nil {
-		return err
+		return Result{}, err
 	}
 
+	tag := Latest(tags)
+
 	version, err := tag.asVersion()
 	if err != nil {
-		return err
+		return Result{}, err
 	}
 
-	fmt.Println("Current version: ", version)
-
-	commits, err := fetcher.CommitsSince(tag)
+	all, err := fetcher.CommitsSince(ctx, tag)
 	if err != nil {
-		return err
+		return Result{}, err
 	}
 
-	var major, minor, patch bool
-	for _, commit := range commits {
-		fmt.Printf("Commit %s %q\n", commit.sha, commit.message)
+	if len(all) == 0 && tag != "" {
+		head, err := fetcher.HeadTags(ctx)
+		if err != nil {
+			return Result{}, err
+		}
 
-		change, _ := commit.Change()
-		switch change {
-		case Breaking:
-			major = true
-		case Feat:
-			minor = true
-		case Fix:
-			patch = true
+		if slices.Contains(head, tag) {
+			return tagged(ctx, fetcher, tag, tags, head, rules)
 		}
 	}
 
+	out, level := classify(tag, version, all, rules)
+
+	major, minor, patch := level == MajorBump, level == MinorBump, level == PatchBump
+
 	newVersion := version.bump(major, minor, patch)
+	if opts.PreRelease != "" {
+		newVersion = version.bumpPreRelease(major, minor, patch, opts.PreRelease)
+	}
 
 	if version.equals(newVersion) {
+		return out, nil
+	}
+
+	out.Version, out.Bump = newVersion, level
+
+	if out.SHA, err = fetcher.Head(ctx); err != nil {
+		return Result{}, err
+	}
+
+	return out, nil
+}
+
+// tagged rebuilds the changes of the latest tag when HEAD already carries it, diffing it against the previous tag.
+func tagged(ctx context.Context, fetcher fetcher, current Tag, tags, head []Tag, rules Rules) (Result, error) {
+	previous := Latest(slices.DeleteFunc(slices.Clone(tags), func(t Tag) bool {
+		return slices.Contains(head, t)
+	}))
+
+	version, err := previous.asVersion()
+	if err != nil {
+		return Result{}, err
+	}
+
+	all, err := fetcher.CommitsSince(ctx, previous)
+	if err != nil {
+		return Result{}, err
+	}
+
+	out, level := classify(previous, version, all, rules)
+
+	if out.Version, err = current.asVersion(); err != nil {
+		return Result{}, err
+	}
+	out.Bump, out.Tagged = level, true
+
+	if out.SHA, err = fetcher.Head(ctx); err != nil {
+		return Result{}, err
+	}
+
+	return out, nil
+}
+
+func classify(tag Tag, version Version, all []*Commit, rules Rules) (Result, Bump) {
+	commits := make([]*Commit, 0, len(all))
+	for _, commit := range all {
+		if !commit.IsRelease() {
+			commits = append(commits, commit)
+		}
+	}
+
+	out := Result{
+		Changes: Changes{
+			Tag:      tag,
+			Previous: version,
+			Version:  version,
+			Commits:  commits,
+		},
+	}
+
+	level := NoBump
+	for _, commit := range commits {
+		level = max(level, rules.Bump(commit))
+	}
+
+	for _, commit := range commits {
+		bump := rules.Bump(commit)
+		if bump == level && level != NoBump {
+			out.Triggers = append(out.Triggers, commit)
+		}
+
+		if bump == NoBump {
+			typ := "non conventional"
+			if header, err := commit.Header(); err == nil {
+				typ = strings.ToLower(header.Type)
+			}
+
+			if !slices.Contains(out.Ignored, typ) {
+				out.Ignored = append(out.Ignored, typ)
+			}
+		}
+	}
+
+	return out, level
+}
+
+func Process(ctx context.Context, fetcher fetcher, pusher pusher, releaser releaser, opts Options) (Result, error) {
+	out, err := Next(ctx, fetcher, opts)
+	if err != nil {
+		return Result{}, err
+	}
+
+	fmt.Println("Current version: ", out.Previous)
+
+	for _, commit := range out.Commits {
+		fmt.Printf("Commit %s %q\n", commit.sha, commit.subject)
+	}
+
+	if out.Tagged {
+		fmt.Println("Already tagged: ", out.Version)
+
+		out.Release, err = releaser.Release(ctx, out.Changes)
+		return out, err
+	}
+
+	if out.Bump == NoBump {
 		fmt.Println("No version change")
-		return nil
+		return out, nil
+	}
+
+	fmt.Println("New version: ", out.Version)
+
+	if err := pusher.Push(ctx, out.Changes); err != nil {
+		return out, err
+	}
+
+	out.Release, err = releaser.Release(ctx, out.Changes)
+	if err != nil {
+		if opts.Rollback {
+			return out, rollback(ctx, pusher, releaser, out.Changes, err)
+		}
+		return out, err
 	}
 
-	fmt.Println("New version: ", newVersion)
+	return out, nil
+}
+
+func rollback(ctx context.Context, pusher pusher, releaser releaser, changes Changes, cause error) error {
+	ctx = context.WithoutCancel(ctx)
+
+	var errs []error
+
+	if d, ok := releaser.(draftDeleter); ok {
+		if err := d.DeleteDraft(ctx, changes); err != nil {
+			errs = append(errs, fmt.Errorf("delete draft release: %w", err))
+		}
+	}
 
-	if err := pusher.Push(newVersion); err != nil {
-		return err
+	if d, ok := pusher.(tagDeleter); !ok {
+		errs = append(errs, fmt.Errorf("tag %s can't be deleted", changes.Version))
+	} else if err := d.DeleteTag(ctx, changes); err != nil {
+		errs = append(errs, fmt.Errorf("delete tag %s: %w", changes.Version, err))
 	}
 
-	if err := releaser.Release(newVersion, commits); err != nil {
-		return err
+	if len(errs) != 0 {
+		return errors.Join(cause, fmt.Errorf("rollback failed: %w", errors.Join(errs...)))
 	}
 
-	return nil
+	return fmt.Errorf("%w, rolled back tag %s", cause, changes.Version)
 }
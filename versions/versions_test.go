@@ -1,111 +1,81 @@
 package versions
 
 import (
+	"context"
+	"errors"
 	"reflect"
 	"testing"
 )
 
-func TestCommit_Change(t *testing.T) {
+func TestTag_asVersion(t *testing.T) {
 	tests := []struct {
-		name  string
-		msg   string
-		want1 Change
-		want2 string
+		tag     Tag
+		version Version
+		error   string
 	}{
 		{
-			"Breaking",
-			"chore!: drop support for Node 6",
-			Breaking,
-			"drop support for Node 6",
+			tag:   "latest",
+			error: `invalid tag "latest"`,
 		},
 		{
-			"Breaking (scope)",
-			"feat(api)!: ASD123: send an email to the customer when a product is shipped",
-			Breaking,
-			"ASD123: send an email to the customer when a product is shipped",
+			tag:   "valpha",
+			error: `invalid tag "valpha"`,
 		},
 		{
-			"Feat",
-			"feat: allow provided config object to extend other configs",
-			Feat,
-			"allow provided config object to extend other configs",
+			tag:   "v",
+			error: `invalid tag "v"`,
 		},
 		{
-			"Feat (scope)",
-			"feat(lang): add Polish language",
-			Feat,
-			"add Polish language",
+			tag:     "v1",
+			version: Version{major: 1},
 		},
 		{
-			"Fix",
-			"fix: qwerty:prevent racing of requests",
-			Fix,
-			"qwerty:prevent racing of requests",
+			tag:     "v1.2",
+			version: Version{major: 1, minor: 2},
 		},
 		{
-			"Fix (scope)",
-			"fix(lang): prevent racing of requests",
-			Fix,
-			"prevent racing of requests",
+			tag:     "v1.2.3",
+			version: Version{major: 1, minor: 2, patch: 3},
 		},
 		{
-			"None",
-			"docs: correct spelling of CHANGELOG",
-			None,
-			"correct spelling of CHANGELOG",
+			tag:   "v1.2.3.4",
+			error: `invalid tag "v1.2.3.4"`,
 		},
 		{
-			"None (scope)",
-			"docs(lang): update ref docs",
-			None,
-			"update ref docs",
+			tag:     "v1.4.0-rc.1",
+			version: Version{major: 1, minor: 4, pre: []string{"rc", "1"}},
 		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			commit := &Commit{
-				message: tt.msg,
-			}
-			if got1, got2 := commit.Change(); got1 != tt.want1 || got2 != tt.want2 {
-				t.Errorf("Change() = %v %v, want %v %v", got1, got2, tt.want1, tt.want2)
-			}
-		})
-	}
-}
-
-func TestTag_asVersion(t *testing.T) {
-	tests := []struct {
-		tag     Tag
-		version Version
-		error   string
-	}{
 		{
-			tag:   "latest",
-			error: `invalid tag "latest"`,
+			tag:     "v2-beta",
+			version: Version{major: 2, pre: []string{"beta"}},
 		},
 		{
-			tag:   "valpha",
-			error: `invalid tag "valpha"`,
+			tag:     "v1.0.0-x-y.7.z--92",
+			version: Version{major: 1, pre: []string{"x-y", "7", "z--92"}},
 		},
 		{
-			tag:   "v",
-			error: `invalid tag "v"`,
+			tag:     "v1.2.3+build.5",
+			version: Version{major: 1, minor: 2, patch: 3},
 		},
 		{
-			tag:     "v1",
-			version: Version{1, 0, 0},
+			tag:     "v1.2.3-rc.1+build.5",
+			version: Version{major: 1, minor: 2, patch: 3, pre: []string{"rc", "1"}},
 		},
 		{
-			tag:     "v1.2",
-			version: Version{1, 2, 0},
+			tag:   "v1.2.3-",
+			error: `invalid tag "v1.2.3-"`,
 		},
 		{
-			tag:     "v1.2.3",
-			version: Version{1, 2, 3},
+			tag:   "v1.2.3-rc..1",
+			error: `invalid tag "v1.2.3-rc..1"`,
 		},
 		{
-			tag:   "v1.2.3.4",
-			error: `invalid tag "v1.2.3.4"`,
+			tag:   "v1.2.3-rc.01",
+			error: `invalid tag "v1.2.3-rc.01"`,
+		},
+		{
+			tag:   "v1.2.3-rc_1",
+			error: `invalid tag "v1.2.3-rc_1"`,
 		},
 	}
 	for _, tt := range tests {
@@ -115,6 +85,9 @@ func TestTag_asVersion(t *testing.T) {
 				t.Errorf("asVersion() err = %v, error %v", err, tt.error)
 				return
 			}
+			if tt.error == "" {
+				tt.version.tag = tt.tag
+			}
 			if !reflect.DeepEqual(got, tt.version) {
 				t.Errorf("asVersion() got = %v, want %v", got, tt.version)
 			}
@@ -135,6 +108,7 @@ func TestVersion_String(t *testing.T) {
 		major int
 		minor int
 		patch int
+		pre   []string
 		want  string
 	}{
 		{
@@ -160,6 +134,12 @@ func TestVersion_String(t *testing.T) {
 			patch: 8,
 			want:  "v7.0.8",
 		},
+		{
+			major: 1,
+			minor: 4,
+			pre:   []string{"rc", "1"},
+			want:  "v1.4.0-rc.1",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.want, func(t *testing.T) {
@@ -167,6 +147,7 @@ func TestVersion_String(t *testing.T) {
 				major: tt.major,
 				minor: tt.minor,
 				patch: tt.patch,
+				pre:   tt.pre,
 			}
 			if got := v.String(); got != tt.want {
 				t.Errorf("String() = %v, want %v", got, tt.want)
@@ -174,3 +155,432 @@ func TestVersion_String(t *testing.T) {
 		})
 	}
 }
+
+func TestVersion_bumpPreRelease(t *testing.T) {
+	tests := []struct {
+		version             Tag
+		major, minor, patch bool
+		channel             string
+		want                string
+	}{
+		{version: "v1.3", minor: true, channel: "rc", want: "v1.4.0-rc.1"},
+		{version: "v1.3", channel: "rc", want: "v1.3"},
+		{version: "v1.4.0-rc.1", patch: true, channel: "rc", want: "v1.4.0-rc.2"},
+		{version: "v1.4.0-rc.1", minor: true, channel: "rc", want: "v1.4.0-rc.2"},
+		{version: "v1.4.0-rc.1", major: true, channel: "rc", want: "v2.0.0-rc.1"},
+		{version: "v1.4.0-rc.1", channel: "rc", want: "v1.4.0-rc.1"},
+		{version: "v1.4.0-rc.3", patch: true, channel: "beta", want: "v1.4.0-beta.1"},
+		{version: "v1.4.1-beta.1", minor: true, channel: "beta", want: "v1.5.0-beta.1"},
+		{version: "v2.0.0-alpha.pre.9", major: true, channel: "alpha.pre", want: "v2.0.0-alpha.pre.10"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.version)+" "+tt.want, func(t *testing.T) {
+			v, err := tt.version.asVersion()
+			if err != nil {
+				t.Fatalf("asVersion() err = %v", err)
+			}
+			if got := v.bumpPreRelease(tt.major, tt.minor, tt.patch, tt.channel); got.String() != tt.want {
+				t.Errorf("bumpPreRelease() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersion_bump(t *testing.T) {
+	tests := []struct {
+		version             Tag
+		major, minor, patch bool
+		want                string
+	}{
+		{version: "v1.2.3", patch: true, want: "v1.2.4"},
+		{version: "v1.2.3", minor: true, want: "v1.3"},
+		{version: "v1.2.3", major: true, want: "v2"},
+		{version: "v1.4.0-rc.2", patch: true, want: "v1.4"},
+		{version: "v1.4.0-rc.2", minor: true, want: "v1.4"},
+		{version: "v1.4.0-rc.2", major: true, want: "v2"},
+		{version: "v2.0.0-rc.2", major: true, want: "v2"},
+		{version: "v1.4.2-rc.2", minor: true, want: "v1.5"},
+		{version: "v1.4.0-rc.2", want: "v1.4.0-rc.2"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.version)+" "+tt.want, func(t *testing.T) {
+			v, err := tt.version.asVersion()
+			if err != nil {
+				t.Fatalf("asVersion() err = %v", err)
+			}
+			if got := v.bump(tt.major, tt.minor, tt.patch); got.String() != tt.want {
+				t.Errorf("bump() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersion_Compare(t *testing.T) {
+	ordered := []Tag{
+		"v1.0.0-alpha",
+		"v1.0.0-alpha.1",
+		"v1.0.0-alpha.beta",
+		"v1.0.0-beta",
+		"v1.0.0-beta.2",
+		"v1.0.0-beta.11",
+		"v1.0.0-rc.1",
+		"v1.0.0",
+		"v1.0.1",
+		"v1.1.0",
+		"v2.0.0",
+		"v10.0.0",
+	}
+	for i := 0; i < len(ordered)-1; i++ {
+		lower, _ := ordered[i].asVersion()
+		higher, _ := ordered[i+1].asVersion()
+
+		if got := lower.Compare(higher); got != -1 {
+			t.Errorf("%s.Compare(%s) = %d, want -1", ordered[i], ordered[i+1], got)
+		}
+		if got := higher.Compare(lower); got != 1 {
+			t.Errorf("%s.Compare(%s) = %d, want 1", ordered[i+1], ordered[i], got)
+		}
+		if got := lower.Compare(lower); got != 0 {
+			t.Errorf("%s.Compare(%s) = %d, want 0", ordered[i], ordered[i], got)
+		}
+	}
+}
+
+func TestLatest(t *testing.T) {
+	tests := []struct {
+		name string
+		tags []Tag
+		want Tag
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name: "invalid only",
+			tags: []Tag{"latest", "nightly"},
+		},
+		{
+			name: "hotfix on old branch",
+			tags: []Tag{"v1.2.1", "v2.0.0", "v1.10.0", "latest"},
+			want: "v2.0.0",
+		},
+		{
+			name: "pre-release",
+			tags: []Tag{"v1.4.0-rc.2", "v1.3.0", "v1.4.0-rc.10", "v1.4.0-beta.5"},
+			want: "v1.4.0-rc.10",
+		},
+		{
+			name: "release over pre-release",
+			tags: []Tag{"v1.4.0-rc.2", "v1.4.0"},
+			want: "v1.4.0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Latest(tt.tags); got != tt.want {
+				t.Errorf("Latest() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewest(t *testing.T) {
+	got := Newest([]Tag{"v1.2", "latest", "v2.0.0-rc.1", "v1.10", "v1.2.0", "v1.9.9"})
+
+	if want := []Tag{"v2.0.0-rc.1", "v1.10", "v1.9.9", "v1.2", "v1.2.0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Newest() = %v, want %v", got, want)
+	}
+}
+
+type fakeFetcher struct {
+	tags, head []Tag
+	commits    map[Tag][]*Commit
+}
+
+func (f *fakeFetcher) Tags(context.Context) ([]Tag, error) {
+	return f.tags, nil
+}
+
+func (f *fakeFetcher) HeadTags(context.Context) ([]Tag, error) {
+	return f.head, nil
+}
+
+func (f *fakeFetcher) CommitsSince(_ context.Context, tag Tag) ([]*Commit, error) {
+	return f.commits[tag], nil
+}
+
+func (f *fakeFetcher) Head(context.Context) (string, error) {
+	return "head", nil
+}
+
+type fakePusher struct {
+	pushed []Version
+	shas   []string
+
+	deleted   []Version
+	deleteErr error
+}
+
+func (f *fakePusher) DeleteTag(_ context.Context, changes Changes) error {
+	f.deleted = append(f.deleted, changes.Version)
+	return f.deleteErr
+}
+
+func (f *fakePusher) Push(_ context.Context, changes Changes) error {
+	f.pushed = append(f.pushed, changes.Version)
+	f.shas = append(f.shas, changes.SHA)
+	return nil
+}
+
+type fakeReleaser struct {
+	released []Version
+	err      error
+	drafts   int
+}
+
+func (f *fakeReleaser) Release(_ context.Context, changes Changes) (Release, error) {
+	f.released = append(f.released, changes.Version)
+	if f.err != nil {
+		return Release{}, f.err
+	}
+	return Release{ID: 1}, nil
+}
+
+func (f *fakeReleaser) DeleteDraft(context.Context, Changes) error {
+	f.drafts++
+	return nil
+}
+
+func TestProcess(t *testing.T) {
+	tests := []struct {
+		name    string
+		tag     Tag
+		commits []string
+		opts    Options
+		want    string
+		bump    Bump
+		ignored []string
+	}{
+		{
+			name:    "no change",
+			tag:     "v1.2.3",
+			commits: []string{"docs: x", "perf: y", "Docs: z", "wip"},
+			ignored: []string{"docs", "perf", "non conventional"},
+		},
+		{
+			name:    "default rules",
+			tag:     "v1.2.3",
+			commits: []string{"fix: x", "feat: y"},
+			want:    "v1.3",
+			bump:    MinorBump,
+		},
+		{
+			name:    "custom rules",
+			tag:     "v1.2.3",
+			commits: []string{"docs: x", "perf: y"},
+			opts:    Options{Rules: DefaultRules().Set(Rule{Type: "perf", Bump: PatchBump})},
+			want:    "v1.2.4",
+			bump:    PatchBump,
+			ignored: []string{"docs"},
+		},
+		{
+			name:    "release commits skipped",
+			tag:     "v1.2.3",
+			commits: []string{"fix(release): v1.2.3\n\nTagger-Release: v1.2.3", "docs: x"},
+			ignored: []string{"docs"},
+		},
+		{
+			name:    "pre-release",
+			tag:     "v1.2.3",
+			commits: []string{"feat: x"},
+			opts:    Options{PreRelease: "rc"},
+			want:    "v1.3.0-rc.1",
+			bump:    MinorBump,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher := &fakeFetcher{tags: []Tag{tt.tag}, commits: map[Tag][]*Commit{}}
+			for _, msg := range tt.commits {
+				fetcher.commits[tt.tag] = append(fetcher.commits[tt.tag], NewCommit("sha", msg))
+			}
+			pusher, releaser := &fakePusher{}, &fakeReleaser{}
+
+			res, err := Process(context.Background(), fetcher, pusher, releaser, tt.opts)
+			if err != nil {
+				t.Fatalf("Process() err = %v", err)
+			}
+
+			if res.Previous.String() != "v1.2.3" {
+				t.Errorf("Process() Previous = %v, want v1.2.3", res.Previous)
+			}
+			if tt.bump != NoBump && len(res.Triggers) == 0 {
+				t.Errorf("Process() Triggers is empty")
+			}
+			if !reflect.DeepEqual(res.Ignored, tt.ignored) {
+				t.Errorf("Process() Ignored = %v, want %v", res.Ignored, tt.ignored)
+			}
+			if res.Bump != tt.bump {
+				t.Errorf("Process() Bump = %v, want %v", res.Bump, tt.bump)
+			}
+
+			var got string
+			if len(pusher.pushed) != 0 {
+				got = pusher.pushed[0].String()
+			}
+			if got != tt.want {
+				t.Errorf("Process() pushed = %v, want %v", got, tt.want)
+			}
+			if len(pusher.shas) != 0 && pusher.shas[0] != "head" {
+				t.Errorf("Process() pushed SHA = %v, want head", pusher.shas[0])
+			}
+			if len(releaser.released) != len(pusher.pushed) {
+				t.Errorf("Process() released = %v, pushed %v", releaser.released, pusher.pushed)
+			}
+		})
+	}
+}
+
+func TestProcess_tagged(t *testing.T) {
+	tests := []struct {
+		name     string
+		tags     []Tag
+		head     []Tag
+		commits  map[Tag][]*Commit
+		previous string
+		version  string
+		tagged   bool
+		pushed   int
+	}{
+		{
+			name:     "head tagged",
+			tags:     []Tag{"v1.2.3", "v1.3", "v1.3.0-rc.1", "other"},
+			head:     []Tag{"v1.3", "v1.3.0-rc.1"},
+			commits:  map[Tag][]*Commit{"v1.2.3": {NewCommit("a", "feat: x")}},
+			previous: "v1.2.3",
+			version:  "v1.3",
+			tagged:   true,
+		},
+		{
+			name:     "first tag",
+			tags:     []Tag{"v0.1"},
+			head:     []Tag{"v0.1"},
+			commits:  map[Tag][]*Commit{"": {NewCommit("a", "feat: x")}},
+			previous: "v0",
+			version:  "v0.1",
+			tagged:   true,
+		},
+		{
+			name:     "head tagged with an older version",
+			tags:     []Tag{"v1.2.3", "v1.3"},
+			head:     []Tag{"v1.2.3"},
+			previous: "v1.3",
+			version:  "v1.3",
+		},
+		{
+			name:     "head tagged with three parts",
+			tags:     []Tag{"v1.2.3", "v1.3.0"},
+			head:     []Tag{"v1.3.0"},
+			commits:  map[Tag][]*Commit{"v1.2.3": {NewCommit("a", "feat: x")}},
+			previous: "v1.2.3",
+			version:  "v1.3.0",
+			tagged:   true,
+		},
+		{
+			name:     "new commits",
+			tags:     []Tag{"v1.2.3"},
+			head:     []Tag{"v1.2.3"},
+			commits:  map[Tag][]*Commit{"v1.2.3": {NewCommit("a", "fix: x")}},
+			previous: "v1.2.3",
+			version:  "v1.2.4",
+			pushed:   1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pusher, releaser := &fakePusher{}, &fakeReleaser{}
+
+			res, err := Process(context.Background(), &fakeFetcher{tags: tt.tags, head: tt.head, commits: tt.commits}, pusher, releaser, Options{})
+			if err != nil {
+				t.Fatalf("Process() err = %v", err)
+			}
+
+			if res.Previous.String() != tt.previous || res.Version.String() != tt.version || res.Tagged != tt.tagged {
+				t.Errorf("Process() got = %v → %v tagged %t, want %v → %v tagged %t", res.Previous, res.Version, res.Tagged, tt.previous, tt.version, tt.tagged)
+			}
+			if len(pusher.pushed) != tt.pushed {
+				t.Errorf("Process() pushed = %v, want %d", pusher.pushed, tt.pushed)
+			}
+			if tt.tagged && (len(releaser.released) != 1 || res.SHA != "head") {
+				t.Errorf("Process() released = %v, SHA = %q", releaser.released, res.SHA)
+			}
+		})
+	}
+}
+
+type plainPusher struct{}
+
+func (plainPusher) Push(context.Context, Changes) error {
+	return nil
+}
+
+func TestProcess_rollback(t *testing.T) {
+	fetcher := &fakeFetcher{tags: []Tag{"v1"}, commits: map[Tag][]*Commit{"v1": {NewCommit("a", "fix: x")}}}
+	failure := errors.New("release failed")
+
+	tests := []struct {
+		name    string
+		pusher  pusher
+		opts    Options
+		want    string
+		deleted int
+		drafts  int
+	}{
+		{
+			name:    "rolled back",
+			pusher:  &fakePusher{},
+			opts:    Options{Rollback: true},
+			want:    "release failed, rolled back tag v1.0.1",
+			deleted: 1,
+			drafts:  1,
+		},
+		{
+			name:    "rollback failure",
+			pusher:  &fakePusher{deleteErr: errors.New("denied")},
+			opts:    Options{Rollback: true},
+			want:    "release failed\nrollback failed: delete tag v1.0.1: denied",
+			deleted: 1,
+			drafts:  1,
+		},
+		{
+			name:   "unsupported",
+			pusher: plainPusher{},
+			opts:   Options{Rollback: true},
+			want:   "release failed\nrollback failed: tag v1.0.1 can't be deleted",
+			drafts: 1,
+		},
+		{
+			name:   "disabled",
+			pusher: &fakePusher{},
+			want:   "release failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			releaser := &fakeReleaser{err: failure}
+
+			_, err := Process(context.Background(), fetcher, tt.pusher, releaser, tt.opts)
+			if err == nil || err.Error() != tt.want || !errors.Is(err, failure) {
+				t.Errorf("Process() err = %v, want %s", err, tt.want)
+			}
+
+			var deleted int
+			if p, ok := tt.pusher.(*fakePusher); ok {
+				deleted = len(p.deleted)
+			}
+			if deleted != tt.deleted || releaser.drafts != tt.drafts {
+				t.Errorf("Process() deleted tags = %d, drafts = %d, want %d, %d", deleted, releaser.drafts, tt.deleted, tt.drafts)
+			}
+		})
+	}
+}